@@ -0,0 +1,251 @@
+package cmdline
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// BindStruct declares a flag or argument for every tagged field of the struct
+// ptr points to.  A field tagged `cmdline:"jobs,j"` becomes the flag --jobs
+// with the short name -j, either name may be left empty.  A field tagged
+// `arg:"file"` becomes a required argument, or the excess arguments if it is a
+// slice.  Other fields are ignored.
+//
+// The ValueHandler is chosen from the field's type: strings, bools, integers
+// of every size, float64, time.Duration, time.Time and slices of them.  More
+// tags refine the declaration:
+//
+//	help:"..."         Flag.Help or Argument.Help
+//	default:"32"       Flag.Default
+//	env:"JOBS"         Flag.Env
+//	required:"true"    the flag must be given at least once
+//	enum:"json,yaml"   a string field accepts only these values
+//	sep:","            a slice field accepts several values separated by sep
+//	count:"true"       an integer field counts how often the flag is given
+//	negatable:"true"   a bool field also accepts --no-<long>
+//	implicit:"always"  the flag's value is optional and defaults to this
+//
+// A bool field is a switch that takes no value.  With an env or default tag
+// it also accepts an attached value, as in --force=false.
+func (c *Command) BindStruct(ptr interface{}) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("BindStruct needs a pointer to a struct, got %T.", ptr))
+	}
+	v = v.Elem()
+	t := v.Type()
+	structName := t.Name()
+	if structName == "" {
+		structName = "struct"
+	}
+	flags := []*Flag{}
+	args := []*Argument{}
+	var excess *Argument
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		names, isFlag := field.Tag.Lookup("cmdline")
+		argName, isArg := field.Tag.Lookup("arg")
+		if !isFlag && !isArg {
+			continue
+		}
+		where := structName + "." + field.Name
+		if isFlag && isArg {
+			panic(where + " cannot be both a flag and an argument.")
+		}
+		if !field.IsExported() {
+			panic(where + " is not exported.")
+		}
+		if isFlag {
+			flags = append(flags, bindFlag(where, names, field.Tag, v.Field(i)))
+			continue
+		}
+		a := &Argument{
+			Name:  argName,
+			Help:  field.Tag.Get("help"),
+			Value: bindValue(where, field.Tag, v.Field(i)),
+		}
+		if a.Name == "" {
+			a.Name = strings.ToLower(field.Name)
+		}
+		if field.Type.Kind() != reflect.Slice {
+			args = append(args, a)
+		} else if excess != nil {
+			panic(where + " cannot take the excess arguments, they already go to <" + excess.Name + ">.")
+		} else {
+			excess = a
+		}
+	}
+	c.Flags(flags)
+	if len(args) > 0 {
+		c.RequiredArgs(args)
+	}
+	if excess != nil {
+		c.ExcessArguments(excess)
+	}
+}
+
+func tagBool(where string, tag reflect.StructTag, key string) bool {
+	text, ok := tag.Lookup(key)
+	if !ok {
+		return false
+	}
+	value, err := strconv.ParseBool(text)
+	if err != nil {
+		panic(fmt.Sprintf("%s has %s:%#v, expected true or false.", where, key, text))
+	}
+	return value
+}
+
+func bindFlag(where string, names string, tag reflect.StructTag, field reflect.Value) *Flag {
+	long, short, _ := strings.Cut(names, ",")
+	f := &Flag{
+		Long:      long,
+		Help:      tag.Get("help"),
+		Default:   tag.Get("default"),
+		Env:       tag.Get("env"),
+		Negatable: tagBool(where, tag, "negatable"),
+	}
+	if short != "" {
+		r, size := utf8.DecodeRuneInString(short)
+		if size != len(short) || r == '-' {
+			panic(fmt.Sprintf("%s has short name %#v, expected a single character.", where, short))
+		}
+		f.Short = r
+	}
+	if tagBool(where, tag, "required") {
+		f.Min = 1
+	}
+	f.Implicit, f.ValueOptional = tag.Lookup("implicit")
+	switch {
+	case tagBool(where, tag, "count"):
+		f.Value = bindCounter(where, field)
+	case field.Kind() == reflect.Bool && !f.Negatable && !f.ValueOptional && f.Env == "" && f.Default == "":
+		// A plain switch, so that it doesn't require a value.
+		f.Call = SetTrue(field.Addr().Convert(reflect.TypeOf((*bool)(nil))).Interface().(*bool))
+	case field.Kind() == reflect.Bool && !f.Negatable && !f.ValueOptional:
+		// The environment and default need a value handler, the value stays
+		// optional so that the flag still works as a switch.
+		f.ValueOptional = true
+		f.Implicit = "true"
+		f.Value = bindValue(where, tag, field)
+	default:
+		f.Value = bindValue(where, tag, field)
+	}
+	return f
+}
+
+func counterFor[T signed | unsigned](field reflect.Value) ValueHandler {
+	return Counter(field.Addr().Convert(reflect.TypeOf((*T)(nil))).Interface().(*T))
+}
+
+func bindCounter(where string, field reflect.Value) ValueHandler {
+	switch field.Kind() {
+	case reflect.Int:
+		return counterFor[int](field)
+	case reflect.Int8:
+		return counterFor[int8](field)
+	case reflect.Int16:
+		return counterFor[int16](field)
+	case reflect.Int32:
+		return counterFor[int32](field)
+	case reflect.Int64:
+		return counterFor[int64](field)
+	case reflect.Uint:
+		return counterFor[uint](field)
+	case reflect.Uint8:
+		return counterFor[uint8](field)
+	case reflect.Uint16:
+		return counterFor[uint16](field)
+	case reflect.Uint32:
+		return counterFor[uint32](field)
+	case reflect.Uint64:
+		return counterFor[uint64](field)
+	}
+	panic(fmt.Sprintf("%s is a %s and cannot count, use an integer.", where, field.Type()))
+}
+
+// bindValue picks the ValueHandler for the field's type, appending to slices
+// and setting anything else.
+func bindValue(where string, tag reflect.StructTag, field reflect.Value) ValueHandler {
+	element := field.Type()
+	if element.Kind() == reflect.Slice {
+		element = element.Elem()
+	}
+	var handler ValueHandler
+	switch {
+	case element == reflect.TypeOf(time.Duration(0)):
+		handler = setOrAppend(Duration, field)
+	case element == reflect.TypeOf(time.Time{}):
+		handler = setOrAppend(Time, field)
+	case element.Kind() == reflect.String:
+		possible, ok := tag.Lookup("enum")
+		if ok {
+			handler = setOrAppend[string](&Enum{Possible: strings.Split(possible, ",")}, field)
+		} else {
+			handler = setOrAppend(String, field)
+		}
+	case element.Kind() == reflect.Bool:
+		handler = setOrAppend(Bool, field)
+	case element.Kind() == reflect.Int:
+		handler = setOrAppend(Int, field)
+	case element.Kind() == reflect.Int8:
+		handler = setOrAppend(Int8, field)
+	case element.Kind() == reflect.Int16:
+		handler = setOrAppend(Int16, field)
+	case element.Kind() == reflect.Int32:
+		handler = setOrAppend(Int32, field)
+	case element.Kind() == reflect.Int64:
+		handler = setOrAppend(Int64, field)
+	case element.Kind() == reflect.Uint:
+		handler = setOrAppend(Uint, field)
+	case element.Kind() == reflect.Uint8:
+		handler = setOrAppend(Uint8, field)
+	case element.Kind() == reflect.Uint16:
+		handler = setOrAppend(Uint16, field)
+	case element.Kind() == reflect.Uint32:
+		handler = setOrAppend(Uint32, field)
+	case element.Kind() == reflect.Uint64:
+		handler = setOrAppend(Uint64, field)
+	case element.Kind() == reflect.Float64:
+		handler = setOrAppend(Float64, field)
+	}
+	if handler == nil {
+		panic(fmt.Sprintf("%s has unsupported type %s.", where, field.Type()))
+	}
+	if _, ok := tag.Lookup("enum"); ok && element.Kind() != reflect.String {
+		panic(where + " has an enum tag but is not a string.")
+	}
+	separator, ok := tag.Lookup("sep")
+	if ok {
+		if field.Kind() != reflect.Slice {
+			panic(where + " has a sep tag but is not a slice.")
+		}
+		if separator == "" {
+			panic(where + " has an empty sep tag.")
+		}
+		handler = Separated(separator, handler)
+	}
+	return handler
+}
+
+// setOrAppend converts the field's address to *T or *[]T, so that named types
+// work, and returns the matching handler.  It returns nil if the conversion is
+// not possible.
+func setOrAppend[T any](factory HandlerFactory[T], field reflect.Value) ValueHandler {
+	if field.Kind() == reflect.Slice {
+		target := reflect.TypeOf((*[]T)(nil))
+		if !field.Addr().Type().ConvertibleTo(target) {
+			return nil
+		}
+		return factory.Append(field.Addr().Convert(target).Interface().(*[]T))
+	}
+	target := reflect.TypeOf((*T)(nil))
+	if !field.Addr().Type().ConvertibleTo(target) {
+		return nil
+	}
+	return factory.Set(field.Addr().Convert(target).Interface().(*T))
+}
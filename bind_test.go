@@ -0,0 +1,155 @@
+package cmdline
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type level int
+
+type bindOptions struct {
+	Jobs     int32         `cmdline:"jobs,j" default:"32" help:"Run this many jobs." env:"BIND_JOBS"`
+	Format   string        `cmdline:"format" enum:"json,yaml" required:"true"`
+	Force    bool          `cmdline:",f" help:"Don't ask."`
+	Color    bool          `cmdline:"color" negatable:"true" default:"true"`
+	Verbose  level         `cmdline:"verbose,v" count:"true"`
+	Timeout  time.Duration `cmdline:"timeout"`
+	Tags     []string      `cmdline:"tag" sep:","`
+	Target   string        `arg:"target" help:"Where to go."`
+	Files    []string      `arg:"file"`
+	internal string
+}
+
+func TestBindStruct(t *testing.T) {
+	opts := bindOptions{}
+	app := MakeApp("tool")
+	app.BindStruct(&opts)
+	_, err := app.Parse([]string{"-f", "--format", "yaml", "-vv", "--no-color", "--timeout", "5s", "--tag", "a,b", "--tag=c", "home", "x", "y"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(32), opts.Jobs)
+	assert.Equal(t, "yaml", opts.Format)
+	assert.Equal(t, true, opts.Force)
+	assert.Equal(t, false, opts.Color)
+	assert.Equal(t, level(2), opts.Verbose)
+	assert.Equal(t, 5*time.Second, opts.Timeout)
+	assert.Equal(t, []string{"a", "b", "c"}, opts.Tags)
+	assert.Equal(t, "home", opts.Target)
+	assert.Equal(t, []string{"x", "y"}, opts.Files)
+}
+
+func TestBindStructErrors(t *testing.T) {
+	opts := bindOptions{}
+	app := MakeApp("tool")
+	app.BindStruct(&opts)
+	t.Setenv("BIND_JOBS", "many")
+	_, err := app.Parse([]string{"--format", "xml", "home"})
+	assert.EqualError(t, err, "\"xml\" is not in {json,yaml}")
+	_, err = app.Parse([]string{"home"})
+	assert.Error(t, err)
+	usage := err.(*UsageError)
+	assert.Equal(t, []string{
+		"$BIND_JOBS: \"many\" cannot be converted into an int32",
+		"--format is required",
+	}, usage.Messages)
+}
+
+func TestBindStructHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	opts := bindOptions{}
+	app := MakeApp("tool")
+	app.BindStruct(&opts)
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Contains(t, b.String(), "usage: tool [<flags>] <target> [<file>...]\n")
+	assert.Contains(t, b.String(), "    -j/--jobs int32               Run this many jobs. [$BIND_JOBS] [default=32]\n")
+	assert.Contains(t, b.String(), "    <target> string    Where to go.\n")
+}
+
+func TestBindStructPanics(t *testing.T) {
+	app := MakeApp("tool")
+	assert.PanicsWithValue(t, "BindStruct needs a pointer to a struct, got int.", func() {
+		app.BindStruct(3)
+	})
+	assert.PanicsWithValue(t, "struct.Ratio has unsupported type float32.", func() {
+		app.BindStruct(&struct {
+			Ratio float32 `cmdline:"ratio"`
+		}{})
+	})
+	type counted struct {
+		Name string `cmdline:"name" count:"true"`
+	}
+	assert.PanicsWithValue(t, "counted.Name is a string and cannot count, use an integer.", func() {
+		app.BindStruct(&counted{})
+	})
+	type short struct {
+		Name string `cmdline:"name,nm"`
+	}
+	assert.PanicsWithValue(t, "short.Name has short name \"nm\", expected a single character.", func() {
+		app.BindStruct(&short{})
+	})
+	type redefined struct {
+		A string `cmdline:"name"`
+		B string `cmdline:"name"`
+	}
+	assert.PanicsWithValue(t, "Tried to redefine --name", func() {
+		app.BindStruct(&redefined{})
+	})
+}
+
+func TestBindStructIntegers(t *testing.T) {
+	opts := struct {
+		Level  int8   `cmdline:"level"`
+		Offset int16  `cmdline:"offset"`
+		Bytes  []byte `cmdline:"byte"`
+		Quiet  uint16 `cmdline:"quiet,q" count:"true"`
+		Debug  int8   `cmdline:"debug,d" count:"true"`
+	}{}
+	app := MakeApp("tool")
+	app.BindStruct(&opts)
+	_, err := app.Parse([]string{"--level=-3", "--offset", "1000", "--byte=1", "--byte=255", "-qqdq"})
+	assert.NoError(t, err)
+	assert.Equal(t, int8(-3), opts.Level)
+	assert.Equal(t, int16(1000), opts.Offset)
+	assert.Equal(t, []byte{1, 255}, opts.Bytes)
+	assert.Equal(t, uint16(3), opts.Quiet)
+	assert.Equal(t, int8(1), opts.Debug)
+	_, err = app.Parse([]string{"--level=128"})
+	assert.EqualError(t, err, `"128" is out of range for an int8`)
+}
+
+func TestBindStructBoolFallback(t *testing.T) {
+	opts := struct {
+		Force  bool `cmdline:"force,f" env:"BIND_FORCE"`
+		Cache  bool `cmdline:"cache" default:"true"`
+		Strict bool `cmdline:"strict" default:"true" negatable:"true"`
+	}{}
+	app := MakeApp("tool")
+	app.BindStruct(&opts)
+
+	_, err := app.Parse([]string{"-f"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, opts.Force)
+	assert.Equal(t, true, opts.Cache)
+	assert.Equal(t, true, opts.Strict)
+
+	opts.Force = false
+	t.Setenv("BIND_FORCE", "true")
+	_, err = app.Parse([]string{"--cache=false", "--no-strict"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, opts.Force)
+	assert.Equal(t, false, opts.Cache)
+	assert.Equal(t, false, opts.Strict)
+
+	t.Setenv("COLUMNS", "80")
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, `usage: tool [<flags>]
+
+Flags:
+    -f/--force[=bool]   [$BIND_FORCE]
+    --cache[=bool]      [default=true]
+    --[no-]strict       [default=true]
+`, b.String())
+}
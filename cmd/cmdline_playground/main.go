@@ -1,12 +1,50 @@
+// cmdline_playground demonstrates parsing and tab completion.  Run without a
+// spec it parses a fixed set of flags:
+//
+//	cmdline_playground --bar 3 -vv
+//
+// Given a JSON spec, as written by --cli-spec, it parses the arguments after
+// "--" with an App built from the spec and prints the values as JSON, or as
+// shell export lines with --export:
+//
+//	cmdline_playground --spec spec.json [--export] -- args...
+//
+// In export mode the output is meant for eval, so help and other output is
+// written to stderr followed by an "exit 0" line, and errors are followed by
+// an "exit 1" line.  Only tab completions go to stdout.
+//
+// Tab completion works through a wrapper script named after the spec's app
+// that runs cmdline_playground --spec spec.json -- "$@".
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/ncbray/cmdline"
 	"os"
+	"sort"
+	"strings"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--spec" {
+		options := os.Args[2:]
+		export := len(options) > 1 && options[1] == "--export"
+		separator := 1
+		if export {
+			separator = 2
+		}
+		if len(options) <= separator || options[separator] != "--" {
+			fmt.Fprintln(os.Stderr, "usage: cmdline_playground --spec spec.json [--export] -- args...")
+			os.Exit(2)
+		}
+		runSpec(options[0], options[separator+1:], export)
+		return
+	}
+	runDemo()
+}
+
+func runDemo() {
 	var foo bool
 	var bar int32
 	var verbosity int32
@@ -16,33 +54,39 @@ func main() {
 	archEnum := &cmdline.Enum{Possible: []string{"arm", "arm64", "ia32", "x64"}}
 
 	app := cmdline.MakeApp("cmdline_playground")
+	app.Description = "A playground for experimenting with parsing and tab completion."
+	app.HelpFlag()
 	app.Flags([]*cmdline.Flag{
 		{
 			Long:  "foo",
 			Short: 'f',
+			Help:  "Enable foo.",
 			Call:  cmdline.SetTrue(&foo),
 		},
 		{
 			Long:  "bar",
 			Short: 'b',
+			Help:  "A required number.",
 			Value: cmdline.Int32.Set(&bar),
 			Min:   1,
 			Max:   1,
 		},
 		{
-			Long:    "verbosity",
-			Short:   'v',
-			Value:   cmdline.Int32.Set(&verbosity),
-			Default: "0",
+			Long:  "verbosity",
+			Short: 'v',
+			Help:  "How much to log.",
+			Value: cmdline.Counter(&verbosity),
 		},
 		{
 			Long:    "jobs",
 			Short:   'j',
+			Help:    "How many jobs to run in parallel.",
 			Value:   cmdline.Int32.Set(&jobs),
 			Default: "32",
 		},
 		{
 			Long:    "arch",
+			Help:    "The architecture to target.",
 			Value:   archEnum.Set(&arch),
 			Default: "arm64",
 		},
@@ -56,3 +100,111 @@ func main() {
 	fmt.Println("jobs", jobs)
 	fmt.Println("arch", arch)
 }
+
+func runSpec(path string, args []string, export bool) {
+	app, values, err := cmdline.LoadSpec(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR", err)
+		fail(export)
+	}
+	result, err := app.Parse(args)
+	if err != nil {
+		usage, ok := err.(*cmdline.UsageError)
+		if ok {
+			for _, message := range usage.Messages {
+				fmt.Fprintln(os.Stderr, "ERROR", message)
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, "ERROR", err)
+		}
+		os.Stderr.WriteString("\n")
+		app.WriteHelp(os.Stderr)
+		fail(export)
+	}
+	if result.Done {
+		if export && !completionRequest(args) {
+			// Keep help and version text away from eval.
+			os.Stderr.WriteString(result.Output)
+			fmt.Println("exit 0")
+			return
+		}
+		os.Stdout.WriteString(result.Output)
+		return
+	}
+	if export {
+		writeExports(result.Command.Path(), values.Values)
+		return
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"command": result.Command.Path(),
+		"values":  values.Values,
+	}, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// fail exits unsuccessfully, making sure eval stops the script in export
+// mode.
+func fail(export bool) {
+	if export {
+		fmt.Println("exit 1")
+	}
+	os.Exit(1)
+}
+
+// completionRequest is true for the arguments a completion script passes to
+// ask for completions.
+func completionRequest(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "--generate-bash-completion", "--generate-zsh-completion", "--generate-fish-completion":
+		return true
+	}
+	return false
+}
+
+// shellName turns a flag or argument name into a variable name.
+func shellName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func shellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// writeExports prints a line for each value that can be evaluated by a shell.
+// Lists are written one element per line.
+func writeExports(command string, values map[string]interface{}) {
+	fmt.Println("export COMMAND=" + shellQuote(command))
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		text := fmt.Sprint(values[name])
+		list, ok := values[name].([]interface{})
+		if ok {
+			lines := []string{}
+			for _, element := range list {
+				lines = append(lines, fmt.Sprint(element))
+			}
+			text = strings.Join(lines, "\n")
+		}
+		fmt.Println("export " + shellName(name) + "=" + shellQuote(text))
+	}
+}
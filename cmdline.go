@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +13,20 @@ type Logger interface {
 	NumErrors() int
 }
 
+// prefixLogger reports errors with the source of the bad value attached.
+type prefixLogger struct {
+	prefix string
+	log    Logger
+}
+
+func (l *prefixLogger) Error(message string) {
+	l.log.Error(l.prefix + message)
+}
+
+func (l *prefixLogger) NumErrors() int {
+	return l.log.NumErrors()
+}
+
 func SetTrue(value *bool) func() {
 	return func() {
 		*value = true
@@ -25,14 +40,29 @@ func SetFalse(value *bool) func() {
 }
 
 type Flag struct {
-	Long     string
-	Short    rune
-	Value    ValueHandler
-	Call     func()
-	Default  string
-	Min      int
-	Max      int
-	useCount int
+	Long    string
+	Short   rune
+	Help    string
+	Value   ValueHandler
+	Call    func()
+	Default string
+	// Env names an environment variable that supplies the value when the flag
+	// is not given on the command line.
+	Env string
+	// Negatable flags take no argument, instead --<long> gives Value "true"
+	// and an automatically added --no-<long> gives it "false".  Use with a
+	// bool handler such as Bool.Set.
+	Negatable bool
+	// ValueOptional flags only take a value attached to them, as in
+	// --color=always or -calways.  Given alone, Value receives Implicit.
+	ValueOptional bool
+	Implicit      string
+	Min           int
+	Max           int
+	useCount      int
+	// fromFallback is set when the use came from the environment or the config
+	// file rather than the command line.
+	fromFallback bool
 }
 
 func (f *Flag) Name() string {
@@ -49,6 +79,27 @@ func (f *Flag) Name() string {
 	}
 }
 
+// longMode describes how the flag's long form takes a value.
+func (f *Flag) longMode() valueMode {
+	if f.Value == nil || f.Negatable {
+		return noValue
+	} else if f.ValueOptional || isCounter(f.Value) {
+		// --verbose counts, --verbose=3 sets the count.
+		return optionalValue
+	} else {
+		return requiredValue
+	}
+}
+
+// shortMode describes how the flag's short form takes a value.  Counters
+// never take a value in short form so they can be repeated as -vvv.
+func (f *Flag) shortMode() valueMode {
+	if f.Value != nil && isCounter(f.Value) {
+		return noValue
+	}
+	return f.longMode()
+}
+
 func (f *Flag) Required() *Flag {
 	if f.Min < 1 {
 		f.Min = 1
@@ -56,12 +107,38 @@ func (f *Flag) Required() *Flag {
 	return f
 }
 
+// Unlimited can be used as a Flag's Max to allow any number of occurrences.
+const Unlimited = -1
+
+// MaxUses is the number of times the flag may be given, or Unlimited.  A zero
+// Max means once for flags that hold a single value, and unlimited for flags
+// with a repeatable value and negatable flags, where the last use wins.
+// Counters are never limited.
+func (f *Flag) MaxUses() int {
+	if f.Max < 0 || f.Value != nil && isCounter(f.Value) {
+		return Unlimited
+	} else if f.Max > 0 {
+		return f.Max
+	} else if f.Negatable || f.Value != nil && isRepeatable(f.Value) {
+		return Unlimited
+	} else {
+		return 1
+	}
+}
+
+// givenOnCommandLine is true if the flag appeared in the arguments.
+func (f *Flag) givenOnCommandLine() bool {
+	return f.useCount > 0 && !f.fromFallback
+}
+
 func (f *Flag) CanAcceptMore() bool {
-	return f.useCount < f.Max
+	max := f.MaxUses()
+	return max == Unlimited || f.useCount < max
 }
 
 type Argument struct {
 	Name  string
+	Help  string
 	Value ValueHandler
 }
 
@@ -71,320 +148,488 @@ func (a *Argument) ArgumentValue(handler ValueHandler) *Argument {
 }
 
 type App struct {
-	name              string
-	allFlags          []*Flag
-	longToFlag        map[string]*Flag
-	shortToFlag       map[rune]*Flag
-	requiredArguments []*Argument
-	excessArguments   *Argument
-	currentArgument   int
-	completing        bool
-	numErrors         int
-}
-
-func (app *App) indexFlag(flag *Flag) {
-	app.allFlags = append(app.allFlags, flag)
-	if flag.Long != "" {
-		_, ok := app.longToFlag[flag.Long]
-		if ok {
-			panic("Tried to redefine --" + flag.Long)
-		}
-		app.longToFlag[flag.Long] = flag
-	}
-	if flag.Short != 0 {
-		_, ok := app.shortToFlag[flag.Short]
-		if ok {
-			panic("Tried to redefine -" + string(flag.Short))
-		}
-		app.shortToFlag[flag.Short] = flag
-	}
+	*Command
+	current         *Command
+	currentArgument int
+	errors          []string
+
+	version          string
+	helpFlag         *Flag
+	versionFlag      *Flag
+	helpRequested    bool
+	versionRequested bool
+
+	configFlag        *Flag
+	configPath        string
+	configSearchPaths []string
+
+	abbreviations bool
 }
 
-func (app *App) Flags(flags []*Flag) {
-	for _, flag := range flags {
-		if flag.Long == "" && flag.Short == 0 {
-			panic("Flag has no name.")
-		}
-		app.indexFlag(flag)
-		if flag.Value == nil && flag.Call == nil {
-			panic(flag.Name() + " has no effect.")
-		}
-		if flag.Value == nil && flag.Default != "" {
-			panic(flag.Name() + " default value but not value handler.")
-		}
-	}
+// UsageError is returned by Parse when the arguments cannot be accepted.  It
+// lists every problem that was reported while parsing.
+type UsageError struct {
+	Messages []string
 }
 
-func (app *App) RequiredArgs(args []*Argument) {
-	for _, a := range args {
-		app.requiredArguments = append(app.requiredArguments, a)
-	}
+func (e *UsageError) Error() string {
+	return strings.Join(e.Messages, "\n")
 }
 
-func (app *App) ExcessArguments(arg *Argument) {
-	app.excessArguments = arg
+// Result describes a successful Parse.
+type Result struct {
+	// Command is the command selected by the arguments.
+	Command *Command
+	// Done is set when the arguments were a request handled by the App itself,
+	// such as generating tab completions.  The caller should write Output and
+	// exit successfully instead of running the program.
+	Done   bool
+	Output string
 }
 
+// WriteHelp describes the currently selected command, which is the root
+// command until arguments have been parsed.
 func (app *App) WriteHelp(out io.Writer) {
-	io.WriteString(out, "usage: ")
-	io.WriteString(out, app.name)
-	if len(app.allFlags) > 0 {
-		io.WriteString(out, " [<flags>]")
-	}
-	for _, a := range app.requiredArguments {
-		io.WriteString(out, " <")
-		io.WriteString(out, a.Name)
-		io.WriteString(out, ">")
-	}
-
-	if app.excessArguments != nil {
-		a := app.excessArguments
-		io.WriteString(out, " [<")
-		io.WriteString(out, a.Name)
-		io.WriteString(out, ">...]")
-	}
-	out.Write([]byte("\n"))
-
-	if len(app.allFlags) > 0 {
-		io.WriteString(out, "\n")
-		io.WriteString(out, "Flags:\n")
-		for _, f := range app.allFlags {
-			io.WriteString(out, "    ")
-			io.WriteString(out, f.Name())
-			if f.Value != nil {
-				io.WriteString(out, "   ")
-				io.WriteString(out, f.Value.TypeName())
-			}
-			if f.Default != "" {
-				io.WriteString(out, "   default=")
-				io.WriteString(out, f.Default)
-			}
-			if f.Min > 0 {
-				io.WriteString(out, "   required")
-			}
-			io.WriteString(out, "\n")
-		}
+	app.current.WriteHelp(out)
+}
+
+// HelpFlag adds -h/--help flags.  When given, Parse skips validation and
+// returns the help for the selected command as its Output.
+func (app *App) HelpFlag() {
+	app.helpFlag = &Flag{
+		Long:  "help",
+		Short: 'h',
+		Help:  "Show this help and exit.",
+		Call: func() {
+			app.helpRequested = true
+		},
 	}
+	app.Flags([]*Flag{app.helpFlag})
+}
+
+// Version adds a --version flag.  When given, Parse skips validation and
+// returns the version as its Output.
+func (app *App) Version(version string) {
+	app.version = version
+	app.versionFlag = &Flag{
+		Long: "version",
+		Help: "Show the version and exit.",
+		Call: func() {
+			app.versionRequested = true
+		},
+	}
+	app.Flags([]*Flag{app.versionFlag})
+}
 
-	if len(app.requiredArguments) > 0 {
-		displayArg := func(name string, a *Argument) {
-			io.WriteString(out, "    ")
-			io.WriteString(out, name)
-			if a.Value != nil {
-				io.WriteString(out, "   ")
-				io.WriteString(out, a.Value.TypeName())
-			}
-			io.WriteString(out, "\n")
+func (app *App) longFlagInfo(name string) (bool, valueMode) {
+	flag := app.lookupLong(name)
+	if flag != nil {
+		return true, flag.longMode()
+	} else {
+		return false, noValue
+	}
+}
 
-		}
+func (app *App) shortFlagInfo(name rune) (bool, valueMode) {
+	flag := app.current.lookupShort(name)
+	if flag != nil {
+		return true, flag.shortMode()
+	} else {
+		return false, noValue
+	}
+}
 
-		io.WriteString(out, "\n")
-		io.WriteString(out, "Args:\n")
-		for _, a := range app.requiredArguments {
-			displayArg(a.Name, a)
-		}
-		if app.excessArguments != nil {
-			a := app.excessArguments
-			displayArg("<"+a.Name+">...", a)
+// AllowAbbreviations lets a long flag be shortened to any prefix that is not
+// shared with another flag, so --verb can mean --verbosity.  This is opt-in
+// because adding a flag can make a previously valid abbreviation ambiguous.
+func (app *App) AllowAbbreviations() {
+	app.abbreviations = true
+}
+
+// resolveLong finds the flag for a long name.  If the name is an abbreviation
+// that matches several flags, their names are returned instead.
+func (app *App) resolveLong(name string) (longName, []string) {
+	flag := app.current.lookupLong(name)
+	if flag != nil {
+		return longName{name: name, flag: flag}, nil
+	}
+	flag = app.current.lookupNegated(name)
+	if flag != nil {
+		return longName{name: name, flag: flag, negated: true}, nil
+	}
+	if !app.abbreviations || name == "" {
+		return longName{}, nil
+	}
+	var match longName
+	matches := []string{}
+	for _, n := range app.current.longNames() {
+		if strings.HasPrefix(n.name, name) {
+			matches = append(matches, n.name)
+			match = n
 		}
 	}
+	if len(matches) == 1 {
+		return match, nil
+	}
+	return longName{}, matches
 }
 
-func (app *App) longFlagInfo(name string) (bool, bool) {
-	flag, ok := app.longToFlag[name]
-	if ok {
-		return true, flag.Value != nil
-	} else {
-		return false, false
-	}
+func (app *App) lookupLong(name string) *Flag {
+	n, _ := app.resolveLong(name)
+	return n.flag
 }
 
-func (app *App) shortFlagInfo(name rune) (bool, bool) {
-	flag, ok := app.shortToFlag[name]
-	if ok {
-		return true, flag.Value != nil
-	} else {
-		return false, false
+func (app *App) unrecognizedLongFlag(name string) {
+	_, matches := app.resolveLong(name)
+	if len(matches) > 1 {
+		app.Error("ambiguous flag --" + name + ", could be --" + strings.Join(matches, ", --"))
+		return
+	}
+	names := []string{}
+	for _, n := range app.current.longNames() {
+		names = append(names, n.name)
+	}
+	message := "unrecognized flag --" + name
+	suggestion := suggest(name, names)
+	if suggestion != "" {
+		message += ", did you mean --" + suggestion + "?"
 	}
+	app.Error(message)
 }
 
-func (app *App) notifyLongFlag(name string) bool {
-	f := app.longToFlag[name]
-	f.useCount++
+// activate uses a flag that was given without a value.
+func (app *App) activate(f *Flag, negated bool) bool {
+	if f.Negatable {
+		return f.Value.Notify(strconv.FormatBool(!negated), app)
+	}
+	if f.ValueOptional {
+		return f.Value.Notify(f.Implicit, app)
+	}
+	if f.Value != nil {
+		f.Value.(counter).Increment()
+		return true
+	}
 	f.Call()
 	return true
 }
 
+func (app *App) notifyLongFlag(name string) bool {
+	n, _ := app.resolveLong(name)
+	n.flag.useCount++
+	return app.activate(n.flag, n.negated)
+}
+
 func (app *App) notifyLongFlagValue(name string, value string) bool {
-	f := app.longToFlag[name]
+	f := app.lookupLong(name)
 	f.useCount++
 	return f.Value.Notify(value, app)
 }
 
 func (app *App) notifyShortFlag(name rune) bool {
-	f := app.shortToFlag[name]
+	f := app.current.lookupShort(name)
 	f.useCount++
-	f.Call()
-	return true
+	return app.activate(f, false)
 }
 
 func (app *App) notifyShortFlagValue(name rune, value string) bool {
-	f := app.shortToFlag[name]
+	f := app.current.lookupShort(name)
 	f.useCount++
 	return f.Value.Notify(value, app)
 }
 
 func (app *App) notifyArg(value string) bool {
-	if app.currentArgument < len(app.requiredArguments) {
-		a := app.requiredArguments[app.currentArgument]
+	c := app.current
+	if app.currentArgument < len(c.requiredArguments) {
+		a := c.requiredArguments[app.currentArgument]
 		app.currentArgument++
 		return a.Value.Notify(value, app)
-	} else if app.excessArguments != nil {
-		return app.excessArguments.Value.Notify(value, app)
+	} else if c.excessArguments != nil {
+		return c.excessArguments.Value.Notify(value, app)
 	}
 	app.Error("Extra argument: " + value)
 	return true
 }
 
-func (app *App) Error(message string) {
-	if !app.completing {
-		fmt.Println("ERROR", message)
+func (app *App) notifyCommand(name string) bool {
+	c, ok := app.current.nameToCommand[name]
+	if !ok {
+		message := "unrecognized command " + name
+		suggestion := suggest(name, app.current.commandNames())
+		if suggestion != "" {
+			message += ", did you mean " + suggestion + "?"
+		}
+		app.Error(message)
+		return false
 	}
-	app.numErrors++
+	app.current = c
+	app.currentArgument = 0
+	return true
+}
+
+func (app *App) Error(message string) {
+	app.errors = append(app.errors, message)
 }
 
 func (app *App) NumErrors() int {
-	return app.numErrors
+	return len(app.errors)
 }
 
 func (app *App) completeLongFlag(prefix string, c CompletionObserver) {
-	for _, f := range app.allFlags {
-		if !f.CanAcceptMore() {
+	for _, n := range app.current.longNames() {
+		if !n.flag.CanAcceptMore() || app.current.excluded(n.flag) {
 			continue
 		}
-		if f.Long != "" && strings.HasPrefix(f.Long, prefix) {
-			c.FinalCompletion(f.Long)
+		if strings.HasPrefix(n.name, prefix) {
+			c.DescribedCompletion(n.name, n.flag.Help, false)
+			if n.flag.ValueOptional {
+				c.DescribedCompletion(n.name+"=", n.flag.Help, true)
+			}
 		}
 	}
 }
 
 func (app *App) completeShortFlag(c CompletionObserver) {
-	for _, f := range app.allFlags {
-		if !f.CanAcceptMore() {
+	for _, f := range app.current.visibleFlags() {
+		if !f.CanAcceptMore() || app.current.excluded(f) {
 			continue
 		}
 		if f.Short != 0 {
-			if f.Value != nil {
-				c.FinalCompletion(string(f.Short))
-			} else {
-				c.PartialCompletion(string(f.Short))
-			}
+			c.DescribedCompletion(string(f.Short), f.Help, f.shortMode() == noValue)
 		}
 	}
 }
 
 func (app *App) completeLongFlagValue(name string, value string, c CompletionObserver) {
-	app.longToFlag[name].Value.Complete(value, c)
+	app.lookupLong(name).Value.Complete(value, c)
 }
 
 func (app *App) completeShortFlagValue(name rune, value string, c CompletionObserver) {
-	app.shortToFlag[name].Value.Complete(value, c)
+	app.current.lookupShort(name).Value.Complete(value, c)
 }
 
 func (app *App) completeArg(prefix string, c CompletionObserver) {
+	if !app.acceptingArgs() {
+		return
+	}
 	var a *Argument
-	if app.currentArgument < len(app.requiredArguments) {
-		a = app.requiredArguments[app.currentArgument]
-	} else if app.excessArguments != nil {
-		a = app.excessArguments
+	if app.currentArgument < len(app.current.requiredArguments) {
+		a = app.current.requiredArguments[app.currentArgument]
+	} else if app.current.excessArguments != nil {
+		a = app.current.excessArguments
+	}
+	if a.Value != nil {
+		a.Value.Complete(prefix, c)
+	}
+}
+
+func (app *App) completeCommand(prefix string, c CompletionObserver) {
+	for _, sub := range app.current.subcommands {
+		if strings.HasPrefix(sub.Name, prefix) {
+			c.DescribedCompletion(sub.Name, sub.summary(), false)
+		}
 	}
-	a.Value.Complete(prefix, c)
 }
 
 func (app *App) acceptingArgs() bool {
-	return app.currentArgument < len(app.requiredArguments) || app.excessArguments != nil
+	return app.currentArgument < len(app.current.requiredArguments) || app.current.excessArguments != nil
+}
+
+func (app *App) acceptingCommand() bool {
+	return len(app.current.subcommands) > 0
+}
+
+// EnvPrefix gives every flag with a value and a long name an environment
+// variable, derived from the prefix and the flag name: with the prefix "APP"
+// --max-jobs can be set with $APP_MAX_JOBS.  An explicit Flag.Env takes
+// precedence.
+func (app *App) EnvPrefix(prefix string) {
+	app.Command.envPrefix = prefix
+}
+
+// fallback supplies a value for a flag that was not given on the command line,
+// from the environment, the config file, or the flag's default, in that order
+// of preference.  A value from the environment or config file counts as a use
+// of the flag.  The command line wins, so they are ignored for a flag that
+// would conflict with the flags given on the command line.
+func (app *App) fallback(f *Flag, config *configValues) {
+	if !app.current.excluded(f) && (app.notifyEnv(f) || app.notifyConfig(f, config)) {
+		f.fromFallback = true
+		return
+	}
+	if f.Default != "" {
+		f.Value.Notify(f.Default, app)
+	}
+}
+
+// notifyEnv supplies the flag's value from the environment, returning false if
+// the variable is not set.
+func (app *App) notifyEnv(f *Flag) bool {
+	name := app.current.envName(f)
+	if name == "" {
+		return false
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return false
+	}
+	f.useCount++
+	f.Value.Notify(value, &prefixLogger{prefix: "$" + name + ": ", log: app})
+	return true
 }
 
 func (app *App) postParse() bool {
-	for _, f := range app.allFlags {
-		if f.Default != "" && f.useCount == 0 {
-			f.Value.Notify(f.Default, app)
+	config := app.loadConfig()
+	for _, f := range app.current.visibleFlags() {
+		if f.useCount == 0 {
+			app.fallback(f, config)
 		}
 		if f.Min > f.useCount {
 			app.Error(f.Name() + " is required")
 		}
+		max := f.MaxUses()
+		if max != Unlimited && f.useCount > max {
+			app.Error(fmt.Sprintf("%s given %d times, at most %d allowed", f.Name(), f.useCount, max))
+		}
+	}
+	for _, constraint := range app.current.activeConstraints() {
+		constraint.check(app)
+	}
+	c := app.current
+	if len(c.subcommands) > 0 && c.Action == nil {
+		app.Error("a command is required, expected one of: " + strings.Join(c.commandNames(), ", "))
 	}
-	for i := app.currentArgument; i < len(app.requiredArguments); i++ {
-		app.Error(fmt.Sprintf("argument %#v is required", app.requiredArguments[i].Name))
+	for i := app.currentArgument; i < len(c.requiredArguments); i++ {
+		app.Error(fmt.Sprintf("argument %#v is required", c.requiredArguments[i].Name))
 	}
 	return app.NumErrors() == 0
 }
 
-const scriptTemplate = `# Usage: eval "$(%s --bash-completion-script)"
-_%s_bash_autocomplete() {
-    local cur args opts
-    COMPREPLY=()
-    cur="${COMP_WORDS[COMP_CWORD]}"
-    COMP_WORDS+=("")
-    args=("${COMP_WORDS[0]}" "--generate-bash-completion" "${COMP_WORDBREAKS}" "${COMP_WORDS[@]:1:$COMP_CWORD}")
-    opts=$("${args[@]}")
-		local IFS=$'\n'
-    COMPREPLY=($(compgen -W "${opts}"))
-    return 0
-}
-complete -o nospace -F _%s_bash_autocomplete %s
-`
-
-func completionClipPoint(prefix string, compWordbreaks string) int {
-	breaks := []rune(compWordbreaks)
-	chars := []rune(prefix)
-	for i := len(chars) - 1; i >= 0; i-- {
-		for _, r := range breaks {
-			if chars[i] == r {
-				return len(string(chars[:i+1]))
-			}
+func (app *App) reset() {
+	app.current = app.Command
+	app.currentArgument = 0
+	app.errors = nil
+	app.helpRequested = false
+	app.versionRequested = false
+	app.configPath = ""
+	app.Command.walk(func(c *Command) {
+		for _, f := range c.allFlags {
+			f.useCount = 0
+			f.fromFallback = false
 		}
-	}
-	return 0
-}
-
-func (app *App) Run(args []string) {
+	})
+}
+
+// Parse processes the arguments without touching the process: nothing is
+// printed and os.Exit is never called.  Parse may be called repeatedly, each
+// call resets the App's own state: the selected command, errors and how often
+// each flag was given.  Values already delivered to handlers are not reset, so
+// Counter and Append targets keep accumulating across calls and should be
+// cleared by the caller between them.  If the arguments are invalid the error
+// will be a *UsageError.
+func (app *App) Parse(args []string) (*Result, error) {
+	app.reset()
 	if len(args) > 0 {
 		switch args[0] {
 		case "--generate-bash-completion":
-			app.completing = true
-			options, partial := complete(args[2:], app)
-			clipPoint := completionClipPoint(args[len(args)-1], args[1])
-			if len(options) == 1 && !partial {
-				fmt.Println(options[0][clipPoint:] + " ")
-			} else {
-				for _, o := range options {
-					fmt.Println(o[clipPoint:])
-				}
+			compWordbreaks := ""
+			words := []string{}
+			if len(args) > 1 {
+				compWordbreaks = args[1]
+				words = args[2:]
 			}
-			os.Exit(0)
+			output := app.bashCompletions(compWordbreaks, words)
+			app.reset()
+			return &Result{Command: app.current, Done: true, Output: output}, nil
 		case "--bash-completion-script":
-			fmt.Printf(scriptTemplate, app.name, app.name, app.name, app.name)
-			os.Exit(0)
+			output := fmt.Sprintf(scriptTemplate, app.Name, app.Name, app.Name, app.Name)
+			return &Result{Command: app.current, Done: true, Output: output}, nil
+		case "--generate-zsh-completion":
+			output := app.zshCompletions(args[1:])
+			app.reset()
+			return &Result{Command: app.current, Done: true, Output: output}, nil
+		case "--zsh-completion-script":
+			output := fmt.Sprintf(zshScriptTemplate, app.Name, app.Name, app.Name, app.Name, app.Name, app.Name, app.Name)
+			return &Result{Command: app.current, Done: true, Output: output}, nil
+		case "--generate-fish-completion":
+			output := app.fishCompletions(args[1:])
+			app.reset()
+			return &Result{Command: app.current, Done: true, Output: output}, nil
+		case "--fish-completion-script":
+			output := fmt.Sprintf(fishScriptTemplate, app.Name, app.Name, app.Name, app.Name)
+			return &Result{Command: app.current, Done: true, Output: output}, nil
+		case "--cli-spec":
+			var b strings.Builder
+			err := app.WriteSpec(&b)
+			if err != nil {
+				return nil, err
+			}
+			return &Result{Command: app.current, Done: true, Output: b.String()}, nil
+		case "--generate-man-page":
+			var b strings.Builder
+			app.WriteManPage(&b)
+			return &Result{Command: app.current, Done: true, Output: b.String()}, nil
+		case "--generate-markdown":
+			var b strings.Builder
+			app.WriteMarkdown(&b)
+			return &Result{Command: app.current, Done: true, Output: b.String()}, nil
+		case "--generate-html":
+			var b strings.Builder
+			app.WriteHTML(&b)
+			return &Result{Command: app.current, Done: true, Output: b.String()}, nil
 		}
 	}
 	ok := parse(args, app)
+	// Asking for help or the version should work even if the arguments are
+	// incomplete, so it takes priority over validation.
+	if app.helpRequested {
+		var b strings.Builder
+		app.WriteHelp(&b)
+		return &Result{Command: app.current, Done: true, Output: b.String()}, nil
+	}
+	if app.versionRequested {
+		output := app.Name + " " + app.version + "\n"
+		return &Result{Command: app.current, Done: true, Output: output}, nil
+	}
 	if ok {
 		ok = app.postParse()
 	}
 	if !ok {
+		return nil, &UsageError{Messages: app.errors}
+	}
+	return &Result{Command: app.current}, nil
+}
+
+// Run parses the arguments, and then runs the selected command's Action.  On
+// failure the errors and help are printed and the process exits.
+func (app *App) Run(args []string) {
+	result, err := app.Parse(args)
+	if err != nil {
+		usage, ok := err.(*UsageError)
+		if ok {
+			for _, message := range usage.Messages {
+				fmt.Println("ERROR", message)
+			}
+		} else {
+			fmt.Println("ERROR", err)
+		}
 		os.Stdout.WriteString("\n")
 		app.WriteHelp(os.Stderr)
 		os.Exit(1)
 	}
+	if result.Done {
+		os.Stdout.WriteString(result.Output)
+		os.Exit(0)
+	}
+	if result.Command.Action != nil {
+		result.Command.Action()
+	}
 }
 
 func MakeApp(name string) *App {
+	root := makeCommand(name, nil)
 	a := &App{
-		name:        name,
-		allFlags:    []*Flag{},
-		longToFlag:  map[string]*Flag{},
-		shortToFlag: map[rune]*Flag{},
+		Command: root,
+		current: root,
 	}
 	return a
 }
@@ -3,6 +3,9 @@ package cmdline
 import (
 	"bytes"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -12,3 +15,699 @@ func TestHelp(t *testing.T) {
 	app.WriteHelp(&b)
 	assert.Equal(t, "usage: foo\n", b.String())
 }
+
+func makeCommandApp(verbose *bool, message *string, ran *string) *App {
+	app := MakeApp("tool")
+	app.Flags([]*Flag{
+		{
+			Long:  "verbose",
+			Short: 'v',
+			Call:  SetTrue(verbose),
+			Max:   1,
+		},
+	})
+	commit := app.Subcommand("commit")
+	commit.Flags([]*Flag{
+		{
+			Long:  "message",
+			Short: 'm',
+			Value: String.Set(message),
+			Max:   1,
+		},
+	})
+	commit.Action = func() {
+		*ran = "commit"
+	}
+	push := app.Subcommand("push")
+	push.Action = func() {
+		*ran = "push"
+	}
+	return app
+}
+
+func TestSubcommandParse(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	assert.Equal(t, true, parse([]string{"commit", "-v", "--message", "hi"}, app))
+	assert.Equal(t, true, app.postParse())
+	assert.Equal(t, "tool commit", app.current.Path())
+	assert.Equal(t, true, verbose)
+	assert.Equal(t, "hi", message)
+}
+
+func TestSubcommandFlagNotInherited(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	assert.Equal(t, false, parse([]string{"push", "--message", "hi"}, app))
+}
+
+func TestSubcommandMissing(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	assert.Equal(t, true, parse([]string{"-v"}, app))
+	assert.Equal(t, false, app.postParse())
+}
+
+func TestSubcommandComplete(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	options, _ := complete([]string{""}, app)
+	assert.Equal(t, []string{"commit", "push"}, options)
+}
+
+func TestSubcommandCompleteFlags(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	options, _ := complete([]string{"commit", "--"}, app)
+	assert.Equal(t, []string{"--verbose", "--message"}, options)
+}
+
+func TestSubcommandCompleteNoArgs(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "push", "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", result.Output)
+}
+
+func TestSubcommandCompleteAfterFlagValue(t *testing.T) {
+	var bar string
+	app := MakeApp("tool")
+	app.Flags([]*Flag{{Long: "bar", Value: String.Set(&bar)}})
+	app.Subcommand("build").Action = func() {}
+	build := app.Subcommand("bench")
+	build.ExcessArguments(&Argument{Name: "name", Value: (&Enum{Possible: []string{"fast", "slow"}}).Call(func(string) {})})
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--bar=x", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "build\nbench\n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "", "bench", "--bar=x", "f"})
+	assert.NoError(t, err)
+	assert.Equal(t, "fast \n", result.Output)
+}
+
+func TestSubcommandPartialShadowPanics(t *testing.T) {
+	var name string
+	app := MakeApp("t")
+	app.Flags([]*Flag{{Long: "name", Short: 'n', Value: String.Set(&name), Min: 1, Default: "d"}})
+	sub := app.Subcommand("sub")
+	assert.PanicsWithValue(t, "-n on t sub shadows only some of the names of -n/--name on t.", func() {
+		sub.Flags([]*Flag{{Short: 'n', Call: func() {}}})
+	})
+	// Redeclaring every name is allowed.
+	sub.Flags([]*Flag{{Long: "name", Short: 'n', Call: func() {}}})
+
+	other := app.Subcommand("other")
+	other.Flags([]*Flag{{Long: "quiet", Call: func() {}}})
+	assert.Panics(t, func() {
+		app.Flags([]*Flag{{Long: "quiet", Short: 'q', Call: func() {}}})
+	})
+}
+
+func TestShortFlagCompleteAfterFlagValue(t *testing.T) {
+	var name, color, jobs string
+	app := MakeApp("tool")
+	app.Flags([]*Flag{
+		{Long: "name", Value: String.Set(&name)},
+		{Long: "color", Short: 'c', Value: String.Set(&color), ValueOptional: true, Implicit: "always"},
+		{Long: "jobs", Short: 'j', Value: String.Set(&jobs)},
+	})
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--name=x", "-c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "-c \n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "", "--name=x", "-j"})
+	assert.NoError(t, err)
+	assert.Equal(t, "-j \n", result.Output)
+}
+
+func TestSubcommandHelp(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	t.Setenv("COLUMNS", "80")
+	var b bytes.Buffer
+	app.nameToCommand["commit"].WriteHelp(&b)
+	assert.Equal(t, "usage: tool commit [<flags>]\n\nFlags:\n    -v/--verbose\n    -m/--message string\n", b.String())
+}
+
+func TestParseResult(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	result, err := app.Parse([]string{"push"})
+	assert.NoError(t, err)
+	assert.Equal(t, false, result.Done)
+	assert.Equal(t, "tool push", result.Command.Path())
+}
+
+func TestParseUsageError(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	_, err := app.Parse([]string{"-v"})
+	usage, ok := err.(*UsageError)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []string{"a command is required, expected one of: commit, push"}, usage.Messages)
+}
+
+func TestParseRepeatable(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	_, err := app.Parse([]string{"bogus"})
+	assert.EqualError(t, err, "unrecognized command bogus")
+	result, err := app.Parse([]string{"commit", "-m", "hi"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tool commit", result.Command.Path())
+}
+
+func TestParseCompletion(t *testing.T) {
+	var verbose bool
+	var message, ran string
+	app := makeCommandApp(&verbose, &message, &ran)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Done)
+	assert.Equal(t, "commit \n", result.Output)
+}
+
+func TestHelpDescriptions(t *testing.T) {
+	t.Setenv("COLUMNS", "60")
+	var jobs int32
+	var files []string
+	app := MakeApp("foo")
+	app.Description = "Does foo things."
+	app.Epilogue = "See also: bar."
+	app.Flags([]*Flag{
+		{
+			Long:    "jobs",
+			Short:   'j',
+			Help:    "How many jobs to run at once, more is faster until the machine runs out of cores.",
+			Value:   Int32.Set(&jobs),
+			Default: "32",
+		},
+	})
+	app.RequiredArgs([]*Argument{
+		{
+			Name:  "file",
+			Help:  "The file to foo.",
+			Value: String.Call(func(value string) { files = append(files, value) }),
+		},
+	})
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, `usage: foo [<flags>] <file>
+
+Does foo things.
+
+Flags:
+    -j/--jobs int32   How many jobs to run at once, more is
+                      faster until the machine runs out of
+                      cores. [default=32]
+
+Args:
+    <file> string   The file to foo.
+
+See also: bar.
+`, b.String())
+}
+
+func TestHelpCommands(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	app := MakeApp("tool")
+	app.Subcommand("commit").Description = "Record changes.\nMore detail."
+	app.Subcommand("push")
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, "usage: tool <command>\n\nCommands:\n    commit   Record changes.\n    push\n", b.String())
+}
+
+func TestWrapText(t *testing.T) {
+	assert.Equal(t, []string{"aaa bbb", "ccc", "", "dd"}, wrapText("aaa bbb ccc\n\ndd", 8))
+	assert.Equal(t, []string{"aaaaaaaaaa", "b"}, wrapText("aaaaaaaaaa b", 4))
+}
+
+func makeHelpApp() *App {
+	var name string
+	app := MakeApp("foo")
+	app.HelpFlag()
+	app.Version("1.2.3")
+	app.Flags([]*Flag{
+		{
+			Long:  "name",
+			Value: String.Set(&name),
+			Min:   1,
+		},
+	})
+	return app
+}
+
+func TestHelpFlag(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	app := makeHelpApp()
+	result, err := app.Parse([]string{"-h"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Done)
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, b.String(), result.Output)
+}
+
+func TestHelpFlagSubcommand(t *testing.T) {
+	app := makeHelpApp()
+	sub := app.Subcommand("sub")
+	sub.RequiredArgs([]*Argument{{Name: "x", Value: String.Call(func(string) {})}})
+	result, err := app.Parse([]string{"sub", "--help"})
+	assert.NoError(t, err)
+	assert.Equal(t, sub, result.Command)
+	assert.Contains(t, result.Output, "usage: foo sub [<flags>] <x>\n")
+}
+
+func TestVersionFlag(t *testing.T) {
+	app := makeHelpApp()
+	result, err := app.Parse([]string{"--version"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Done)
+	assert.Equal(t, "foo 1.2.3\n", result.Output)
+}
+
+func TestHelpFlagNotGiven(t *testing.T) {
+	app := makeHelpApp()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "--name is required")
+}
+
+func TestZshCompletion(t *testing.T) {
+	app := makeHelpApp()
+	app.Flags([]*Flag{
+		{
+			Short: 'q',
+			Help:  "Be quiet:\nvery quiet.",
+			Call:  func() {},
+			Max:   1,
+		},
+	})
+	app.Subcommand("sub").Description = "A subcommand."
+	result, err := app.Parse([]string{"--generate-zsh-completion", "-"})
+	assert.NoError(t, err)
+	assert.Equal(t, "partial:-h:Show this help and exit.\n"+
+		"partial:-q:Be quiet: very quiet.\n"+
+		"final:--help:Show this help and exit.\n"+
+		"final:--version:Show the version and exit.\n"+
+		"final:--name\n", result.Output)
+	result, err = app.Parse([]string{"--generate-zsh-completion", "s"})
+	assert.NoError(t, err)
+	assert.Equal(t, "final:sub:A subcommand.\n", result.Output)
+}
+
+func TestZshCompletionScript(t *testing.T) {
+	app := MakeApp("foo")
+	result, err := app.Parse([]string{"--zsh-completion-script"})
+	assert.NoError(t, err)
+	assert.Contains(t, result.Output, "compdef _foo_zsh_autocomplete foo\n")
+}
+
+func TestFishCompletion(t *testing.T) {
+	app := makeHelpApp()
+	app.Flags([]*Flag{
+		{
+			Long: "quiet",
+			Help: "Be quiet.",
+			Call: func() {},
+			Max:  1,
+		},
+	})
+	app.Subcommand("sub")
+	result, err := app.Parse([]string{"--generate-fish-completion", "--q"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--quiet\tBe quiet.\n", result.Output)
+	result, err = app.Parse([]string{"--generate-fish-completion", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "sub\n", result.Output)
+}
+
+func TestFishCompletionDirectory(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "file"), nil, 0644))
+	var path string
+	app := MakeApp("foo")
+	app.RequiredArgs([]*Argument{{Name: "path", Value: (&FilePath{Root: root}).Set(&path)}})
+	result, err := app.Parse([]string{"--generate-fish-completion", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "dir/\nfile\n", result.Output)
+}
+
+func TestAppendFlag(t *testing.T) {
+	var include []string
+	var nums []int32
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long:  "include",
+			Short: 'I',
+			Value: String.Append(&include),
+		},
+		{
+			Long:  "num",
+			Value: Separated(",", Int32.Append(&nums)),
+		},
+	})
+	_, err := app.Parse([]string{"-Ia", "--include", "b", "--num=1,2", "--num", "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, include)
+	assert.Equal(t, []int32{1, 2, 3}, nums)
+}
+
+func TestAppendFlagHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	var include []string
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long:  "include",
+			Value: Separated(",", String.Append(&include)),
+		},
+	})
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, "usage: foo [<flags>]\n\nFlags:\n    --include string[,string...]...\n", b.String())
+}
+
+func TestSeparatedComplete(t *testing.T) {
+	var arch []string
+	archEnum := &Enum{Possible: []string{"arm", "arm64", "x64"}}
+	handler := Separated(",", archEnum.Append(&arch))
+	p := &parser{}
+	handler.Complete("x64,ar", p)
+	assert.Equal(t, []string{"x64,arm", "x64,arm64"}, p.completions)
+}
+
+func TestMaxDefaultsToOnce(t *testing.T) {
+	var bar int32
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long:  "bar",
+			Value: Int32.Set(&bar),
+		},
+	})
+	_, err := app.Parse([]string{"--bar", "1", "--bar=2", "--bar", "3"})
+	assert.EqualError(t, err, "--bar given 3 times, at most 1 allowed")
+	_, err = app.Parse([]string{"--bar", "1"})
+	assert.NoError(t, err)
+}
+
+func TestMaxRepeatableUnlimited(t *testing.T) {
+	var include []string
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long:  "include",
+			Value: String.Append(&include),
+		},
+		{
+			Long:  "limited",
+			Value: String.Append(&include),
+			Max:   2,
+		},
+	})
+	_, err := app.Parse([]string{"--include", "a", "--include", "b", "--include", "c"})
+	assert.NoError(t, err)
+	_, err = app.Parse([]string{"--limited", "a", "--limited", "b", "--limited", "c"})
+	assert.EqualError(t, err, "--limited given 3 times, at most 2 allowed")
+}
+
+func TestMaxCompletion(t *testing.T) {
+	var quiet bool
+	var include []string
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long: "quiet",
+			Call: SetTrue(&quiet),
+		},
+		{
+			Long:  "include",
+			Value: String.Append(&include),
+		},
+	})
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--quiet", "--include", "a", "--"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--include \n", result.Output)
+}
+
+func TestMinAboveMaxPanics(t *testing.T) {
+	var bar int32
+	app := MakeApp("foo")
+	assert.Panics(t, func() {
+		app.Flags([]*Flag{{Long: "bar", Value: Int32.Set(&bar), Min: 2}})
+	})
+}
+
+type upperParser struct {
+}
+
+func (p *upperParser) Parse(text string) (string, error) {
+	return strings.ToUpper(text), nil
+}
+
+func (p *upperParser) Complete(text string, observer CompletionObserver) {
+	observer.FinalCompletion(text + "!")
+}
+
+func (p *upperParser) TypeName() string {
+	return "upper"
+}
+
+func TestCustomValue(t *testing.T) {
+	var one string
+	var many []string
+	upper := NewValue[string](&upperParser{})
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{Long: "one", Value: upper.Set(&one)},
+		{Long: "many", Value: upper.Append(&many)},
+	})
+	_, err := app.Parse([]string{"--one", "a", "--many", "b", "--many", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "A", one)
+	assert.Equal(t, []string{"B", "C"}, many)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--one", "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "x! \n", result.Output)
+}
+
+func makeEnvApp(jobs *int32, name *string) *App {
+	app := MakeApp("foo")
+	app.EnvPrefix("APP")
+	app.Flags([]*Flag{
+		{
+			Long:    "max-jobs",
+			Value:   Int32.Set(jobs),
+			Default: "32",
+		},
+		{
+			Long:  "name",
+			Value: String.Set(name),
+			Env:   "FOO_NAME",
+			Min:   1,
+		},
+	})
+	return app
+}
+
+func TestEnvFallback(t *testing.T) {
+	var jobs int32
+	var name string
+	app := makeEnvApp(&jobs, &name)
+	t.Setenv("APP_MAX_JOBS", "4")
+	t.Setenv("FOO_NAME", "env")
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), jobs)
+	assert.Equal(t, "env", name)
+	_, err = app.Parse([]string{"--name", "flag", "--max-jobs", "8"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(8), jobs)
+	assert.Equal(t, "flag", name)
+}
+
+func TestEnvFallbackDefault(t *testing.T) {
+	var jobs int32
+	var name string
+	app := makeEnvApp(&jobs, &name)
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "--name is required")
+	assert.Equal(t, int32(32), jobs)
+}
+
+func TestEnvFallbackError(t *testing.T) {
+	var jobs int32
+	var name string
+	app := makeEnvApp(&jobs, &name)
+	t.Setenv("APP_MAX_JOBS", "many")
+	_, err := app.Parse([]string{"--name", "x"})
+	assert.EqualError(t, err, `$APP_MAX_JOBS: "many" cannot be converted into an int32`)
+}
+
+func TestEnvHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	var jobs int32
+	var name string
+	app := makeEnvApp(&jobs, &name)
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, `usage: foo [<flags>]
+
+Flags:
+    --max-jobs int32   [$APP_MAX_JOBS] [default=32]
+    --name string      [$FOO_NAME] [required]
+`, b.String())
+}
+
+func makeNegatableApp(color *bool) *App {
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long:      "color",
+			Short:     'c',
+			Help:      "Colorize output.",
+			Value:     Bool.Set(color),
+			Negatable: true,
+			Default:   "true",
+		},
+	})
+	return app
+}
+
+func TestNegatableFlag(t *testing.T) {
+	var color bool
+	app := makeNegatableApp(&color)
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, true, color)
+	_, err = app.Parse([]string{"--no-color"})
+	assert.NoError(t, err)
+	assert.Equal(t, false, color)
+	_, err = app.Parse([]string{"--no-color", "--color", "--no-color", "-c"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, color)
+	_, err = app.Parse([]string{"--no-color=yes"})
+	assert.EqualError(t, err, "--no-color does not take an argument")
+	_, err = app.Parse([]string{"--no-colour"})
+	assert.EqualError(t, err, "unrecognized flag --no-colour, did you mean --no-color?")
+}
+
+func TestNegatableFlagComplete(t *testing.T) {
+	var color bool
+	app := makeNegatableApp(&color)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--color", "--"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--color\n--no-color\n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "", "-"})
+	assert.NoError(t, err)
+	assert.Equal(t, "-c\n--color\n--no-color\n", result.Output)
+}
+
+func TestNegatableFlagHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	var color bool
+	app := makeNegatableApp(&color)
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, "usage: foo [<flags>]\n\nFlags:\n    -c/--[no-]color   Colorize output. [default=true]\n", b.String())
+}
+
+func TestNegatableFlagRedefine(t *testing.T) {
+	var color bool
+	app := makeNegatableApp(&color)
+	assert.Panics(t, func() {
+		app.Flags([]*Flag{{Long: "no-color", Call: func() {}}})
+	})
+}
+
+func makeOptionalApp(color *string, verbosity *int) *App {
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{
+			Long:          "color",
+			Short:         'c',
+			Value:         (&Enum{Possible: []string{"always", "auto", "never"}}).Set(color),
+			ValueOptional: true,
+			Implicit:      "always",
+			Default:       "auto",
+		},
+		{
+			Long:  "verbose",
+			Short: 'v',
+			Value: Counter(verbosity),
+		},
+	})
+	app.ExcessArguments(&Argument{Name: "file", Value: String.Call(func(string) {})})
+	return app
+}
+
+func TestOptionalValue(t *testing.T) {
+	var color string
+	var verbosity int
+	app := makeOptionalApp(&color, &verbosity)
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "auto", color)
+	_, err = app.Parse([]string{"--color", "never"})
+	assert.NoError(t, err)
+	assert.Equal(t, "always", color)
+	_, err = app.Parse([]string{"--color=never"})
+	assert.NoError(t, err)
+	assert.Equal(t, "never", color)
+	_, err = app.Parse([]string{"-cnever"})
+	assert.NoError(t, err)
+	assert.Equal(t, "never", color)
+	_, err = app.Parse([]string{"--color=sometimes"})
+	assert.EqualError(t, err, `"sometimes" is not in {always,auto,never}`)
+}
+
+func TestOptionalValueCounter(t *testing.T) {
+	var color string
+	var verbosity int
+	app := makeOptionalApp(&color, &verbosity)
+	_, err := app.Parse([]string{"--verbose=3", "-vv"})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, verbosity)
+}
+
+func TestOptionalValueComplete(t *testing.T) {
+	var color string
+	var verbosity int
+	app := makeOptionalApp(&color, &verbosity)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--co"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--color\n--color=\n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "=", "--color=a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "always\nauto\n", result.Output)
+}
+
+func TestOptionalValueHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	var color string
+	var verbosity int
+	app := makeOptionalApp(&color, &verbosity)
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, `usage: foo [<flags>] [<file>...]
+
+Flags:
+    -c/--color[={always,auto,never}]   [default=auto]
+    -v/--verbose[=count]...
+
+Args:
+    <file>... string
+`, b.String())
+}
@@ -0,0 +1,280 @@
+package cmdline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Command is a node in an App's command tree.  Each command has its own
+// flags, positional arguments and action.  Flags declared on a command are
+// inherited by all of its subcommands.
+type Command struct {
+	Name   string
+	Action func()
+	// Description is shown at the top of the help.  Its first line is used as a
+	// summary when the command is listed by its parent.
+	Description string
+	// Epilogue is shown at the bottom of the help.
+	Epilogue string
+
+	parent            *Command
+	subcommands       []*Command
+	nameToCommand     map[string]*Command
+	allFlags          []*Flag
+	longToFlag        map[string]*Flag
+	negatedToFlag     map[string]*Flag
+	shortToFlag       map[rune]*Flag
+	requiredArguments []*Argument
+	excessArguments   *Argument
+	constraints       []*constraint
+	// Only used on the root command.
+	envPrefix string
+}
+
+func makeCommand(name string, parent *Command) *Command {
+	return &Command{
+		Name:          name,
+		parent:        parent,
+		nameToCommand: map[string]*Command{},
+		allFlags:      []*Flag{},
+		longToFlag:    map[string]*Flag{},
+		negatedToFlag: map[string]*Flag{},
+		shortToFlag:   map[rune]*Flag{},
+	}
+}
+
+// Subcommand declares a new subcommand and returns it so that flags and
+// arguments can be added to it.  A command with subcommands cannot accept
+// positional arguments, its first positional argument selects the
+// subcommand.
+func (c *Command) Subcommand(name string) *Command {
+	if name == "" || strings.HasPrefix(name, "-") {
+		panic("Invalid command name " + name)
+	}
+	if len(c.requiredArguments) > 0 || c.excessArguments != nil {
+		panic(c.Path() + " accepts arguments and cannot have subcommands.")
+	}
+	_, ok := c.nameToCommand[name]
+	if ok {
+		panic("Tried to redefine command " + name)
+	}
+	sub := makeCommand(name, c)
+	c.subcommands = append(c.subcommands, sub)
+	c.nameToCommand[name] = sub
+	return sub
+}
+
+// Path returns the space-separated names of this command and its ancestors.
+func (c *Command) Path() string {
+	if c.parent != nil {
+		return c.parent.Path() + " " + c.Name
+	}
+	return c.Name
+}
+
+// walk calls visit on this command and every command beneath it.
+func (c *Command) walk(visit func(*Command)) {
+	visit(c)
+	for _, sub := range c.subcommands {
+		sub.walk(visit)
+	}
+}
+
+func (c *Command) indexFlag(flag *Flag) {
+	c.allFlags = append(c.allFlags, flag)
+	if flag.Long != "" {
+		if c.longToFlag[flag.Long] != nil || c.negatedToFlag[flag.Long] != nil {
+			panic("Tried to redefine --" + flag.Long)
+		}
+		c.longToFlag[flag.Long] = flag
+	}
+	if flag.Negatable {
+		negated := "no-" + flag.Long
+		if c.longToFlag[negated] != nil || c.negatedToFlag[negated] != nil {
+			panic("Tried to redefine --" + negated)
+		}
+		c.negatedToFlag[negated] = flag
+	}
+	if flag.Short != 0 {
+		_, ok := c.shortToFlag[flag.Short]
+		if ok {
+			panic("Tried to redefine -" + string(flag.Short))
+		}
+		c.shortToFlag[flag.Short] = flag
+	}
+}
+
+// sharesName is true if the flags have a long or short name in common.
+func sharesName(a *Flag, b *Flag) bool {
+	return a.Long != "" && a.Long == b.Long || a.Short != 0 && a.Short == b.Short
+}
+
+// checkShadowing panics if flag would hide only some of the names of a flag on
+// an ancestor or descendant command.  The other names would still parse but
+// the hidden flag would vanish from help, completion and validation.
+func (c *Command) checkShadowing(flag *Flag) {
+	check := func(other *Flag, owner *Command) {
+		if sharesName(flag, other) && (flag.Long != other.Long || flag.Short != other.Short) {
+			panic(flag.Name() + " on " + c.Path() + " shadows only some of the names of " + other.Name() + " on " + owner.Path() + ".")
+		}
+	}
+	for ancestor := c.parent; ancestor != nil; ancestor = ancestor.parent {
+		for _, other := range ancestor.allFlags {
+			check(other, ancestor)
+		}
+	}
+	for _, sub := range c.subcommands {
+		sub.walk(func(descendant *Command) {
+			for _, other := range descendant.allFlags {
+				check(other, descendant)
+			}
+		})
+	}
+}
+
+func (c *Command) Flags(flags []*Flag) {
+	for _, flag := range flags {
+		if flag.Long == "" && flag.Short == 0 {
+			panic("Flag has no name.")
+		}
+		c.checkShadowing(flag)
+		if flag.Negatable && (flag.Long == "" || flag.Value == nil) {
+			panic(flag.Name() + " is negatable and needs a long name and a value handler.")
+		}
+		if flag.ValueOptional && (flag.Value == nil || flag.Negatable) {
+			panic(flag.Name() + " has an optional value and needs a value handler.")
+		}
+		c.indexFlag(flag)
+		if flag.Value == nil && flag.Call == nil {
+			panic(flag.Name() + " has no effect.")
+		}
+		if flag.Value == nil && flag.Default != "" {
+			panic(flag.Name() + " default value but not value handler.")
+		}
+		if flag.Value == nil && flag.Env != "" {
+			panic(flag.Name() + " environment variable but not value handler.")
+		}
+		max := flag.MaxUses()
+		if max != Unlimited && flag.Min > max {
+			panic(fmt.Sprintf("%s requires %d uses but allows at most %d.", flag.Name(), flag.Min, max))
+		}
+	}
+}
+
+func (c *Command) RequiredArgs(args []*Argument) {
+	if len(c.subcommands) > 0 {
+		panic(c.Path() + " has subcommands and cannot accept arguments.")
+	}
+	for _, a := range args {
+		c.requiredArguments = append(c.requiredArguments, a)
+	}
+}
+
+func (c *Command) ExcessArguments(arg *Argument) {
+	if len(c.subcommands) > 0 {
+		panic(c.Path() + " has subcommands and cannot accept arguments.")
+	}
+	c.excessArguments = arg
+}
+
+// lookupLong finds a long flag on this command or, failing that, the
+// nearest ancestor that declares it.
+func (c *Command) lookupLong(name string) *Flag {
+	for current := c; current != nil; current = current.parent {
+		flag, ok := current.longToFlag[name]
+		if ok {
+			return flag
+		}
+	}
+	return nil
+}
+
+// lookupNegated finds a negatable flag by its --no-<long> name.
+func (c *Command) lookupNegated(name string) *Flag {
+	for current := c; current != nil; current = current.parent {
+		flag, ok := current.negatedToFlag[name]
+		if ok {
+			return flag
+		}
+	}
+	return nil
+}
+
+func (c *Command) lookupShort(name rune) *Flag {
+	for current := c; current != nil; current = current.parent {
+		flag, ok := current.shortToFlag[name]
+		if ok {
+			return flag
+		}
+	}
+	return nil
+}
+
+// visibleFlags returns every flag that can be used with this command, in
+// declaration order starting from the root.  Flags shadowed by a descendant
+// redefining the same name are omitted.
+func (c *Command) visibleFlags() []*Flag {
+	inherited := []*Flag{}
+	if c.parent != nil {
+		for _, f := range c.parent.visibleFlags() {
+			if f.Long != "" && c.longToFlag[f.Long] != nil {
+				continue
+			}
+			if f.Short != 0 && c.shortToFlag[f.Short] != nil {
+				continue
+			}
+			inherited = append(inherited, f)
+		}
+	}
+	return append(inherited, c.allFlags...)
+}
+
+func (c *Command) root() *Command {
+	if c.parent != nil {
+		return c.parent.root()
+	}
+	return c
+}
+
+// envName is the environment variable that can supply the flag's value, if
+// any.
+func (c *Command) envName(f *Flag) string {
+	if f.Env != "" {
+		return f.Env
+	}
+	prefix := c.root().envPrefix
+	if prefix == "" || f.Long == "" || f.Value == nil {
+		return ""
+	}
+	return prefix + "_" + strings.ToUpper(strings.ReplaceAll(f.Long, "-", "_"))
+}
+
+// longName is a name that can be given after "--" to use a flag.
+type longName struct {
+	name    string
+	flag    *Flag
+	negated bool
+}
+
+// longNames lists the long names of every visible flag, including the
+// --no-<long> forms of negatable flags.
+func (c *Command) longNames() []longName {
+	names := []longName{}
+	for _, f := range c.visibleFlags() {
+		if f.Long != "" {
+			names = append(names, longName{name: f.Long, flag: f})
+		}
+		if f.Negatable {
+			names = append(names, longName{name: "no-" + f.Long, flag: f, negated: true})
+		}
+	}
+	return names
+}
+
+func (c *Command) commandNames() []string {
+	names := []string{}
+	for _, sub := range c.subcommands {
+		names = append(names, sub.Name)
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+package cmdline
+
+import (
+	"fmt"
+	"strings"
+)
+
+const scriptTemplate = `# Usage: eval "$(%s --bash-completion-script)"
+_%s_bash_autocomplete() {
+    local cur args opts
+    COMPREPLY=()
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    COMP_WORDS+=("")
+    args=("${COMP_WORDS[0]}" "--generate-bash-completion" "${COMP_WORDBREAKS}" "${COMP_WORDS[@]:1:$COMP_CWORD}")
+    opts=$("${args[@]}")
+		local IFS=$'\n'
+    COMPREPLY=($(compgen -W "${opts}"))
+    return 0
+}
+complete -o nospace -F _%s_bash_autocomplete %s
+`
+
+func completionClipPoint(prefix string, compWordbreaks string) int {
+	breaks := []rune(compWordbreaks)
+	chars := []rune(prefix)
+	for i := len(chars) - 1; i >= 0; i-- {
+		for _, r := range breaks {
+			if chars[i] == r {
+				return len(string(chars[:i+1]))
+			}
+		}
+	}
+	return 0
+}
+
+func (app *App) bashCompletions(compWordbreaks string, args []string) string {
+	options, partial := complete(args, app)
+	clipPoint := 0
+	if len(args) > 0 {
+		clipPoint = completionClipPoint(args[len(args)-1], compWordbreaks)
+	}
+	var b strings.Builder
+	if len(options) == 1 && !partial {
+		b.WriteString(options[0][clipPoint:] + " \n")
+	} else {
+		for _, o := range options {
+			b.WriteString(o[clipPoint:] + "\n")
+		}
+	}
+	return b.String()
+}
+
+const zshScriptTemplate = `#compdef %s
+# Usage: eval "$(%s --zsh-completion-script)"
+_%s_zsh_autocomplete() {
+    local -a final partial
+    local line
+    for line in "${(@f)$("${words[1]}" --generate-zsh-completion "${(@)words[2,CURRENT]}")}"; do
+        case $line in
+            final:*) final+=("${line#final:}") ;;
+            partial:*) partial+=("${line#partial:}") ;;
+        esac
+    done
+    _describe -t final %s final
+    _describe -t partial %s partial -S ''
+}
+compdef _%s_zsh_autocomplete %s
+`
+
+// zshCompletions lists one candidate per line in the format
+// "<final|partial>:<candidate>[:<description>]", where colons in the
+// candidate are escaped as _describe expects.  Partial candidates should not
+// be followed by a space.
+func (app *App) zshCompletions(args []string) string {
+	var b strings.Builder
+	for _, c := range describeCompletions(args, app) {
+		kind := "final"
+		if c.partial {
+			kind = "partial"
+		}
+		text := strings.ReplaceAll(c.text, ":", `\:`)
+		description := strings.Join(strings.Fields(c.description), " ")
+		if description != "" {
+			fmt.Fprintf(&b, "%s:%s:%s\n", kind, text, description)
+		} else {
+			fmt.Fprintf(&b, "%s:%s\n", kind, text)
+		}
+	}
+	return b.String()
+}
+
+const fishScriptTemplate = `# Usage: %s --fish-completion-script | source
+function __%s_fish_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    $tokens[1] --generate-fish-completion $tokens[2..-1]
+end
+complete -c %s -f -a '(__%s_fish_complete)'
+`
+
+// fishCompletions lists one candidate per line in the format
+// "<candidate>[\t<description>]".  fish has no way to suppress the trailing
+// space for an individual candidate, but it omits the space after candidates
+// ending in "/" or "=", which covers the partial completions of FilePath and
+// of long flags with a value.
+func (app *App) fishCompletions(args []string) string {
+	var b strings.Builder
+	for _, c := range describeCompletions(args, app) {
+		description := strings.Join(strings.Fields(c.description), " ")
+		if description != "" {
+			fmt.Fprintf(&b, "%s\t%s\n", c.text, description)
+		} else {
+			fmt.Fprintf(&b, "%s\n", c.text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,430 @@
+package cmdline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configValues holds the flag values read from a config file.  Keys are the
+// flag's long name, qualified by the names of the subcommands leading to the
+// command that declares it, joined with dots: "jobs" or "remote.add.fetch".
+type configValues struct {
+	path   string
+	values map[string][]string
+}
+
+// ConfigFile adds a --config flag naming a file of flag values.  If --config
+// is not given the first of searchPaths that exists is used instead.  Values
+// from the file apply to flags not set on the command line or in the
+// environment, and are checked by the flag's ValueHandler exactly as if they
+// had been given on the command line.
+//
+// The format is chosen by the file's extension: .json, .toml, or .ini (also
+// .cfg and .conf).  Subcommand flags are written in a section, or nested
+// object, named after the subcommand.
+func (app *App) ConfigFile(searchPaths ...string) {
+	app.configSearchPaths = searchPaths
+	app.configFlag = &Flag{
+		Long:  "config",
+		Help:  "Read flag values from this file.",
+		Value: (&FilePath{MustExist: true}).Set(&app.configPath),
+	}
+	app.Flags([]*Flag{app.configFlag})
+}
+
+// loadConfig finds and reads the config file, if there is one.
+func (app *App) loadConfig() *configValues {
+	if app.configFlag == nil {
+		return nil
+	}
+	if app.configFlag.useCount == 0 {
+		app.fallback(app.configFlag, nil)
+	}
+	path := app.configPath
+	if app.configFlag.useCount == 0 {
+		for _, candidate := range app.configSearchPaths {
+			_, err := os.Stat(candidate)
+			if err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	config, err := readConfig(path)
+	if err != nil {
+		app.Error(err.Error())
+		return nil
+	}
+	app.checkConfigKeys(config)
+	return config
+}
+
+// checkConfigKeys reports keys that do not correspond to a flag.  Only the
+// sections for the selected command and its ancestors are checked, sections
+// for other commands may be intended for other invocations.
+func (app *App) checkConfigKeys(config *configValues) {
+	known := map[string]bool{}
+	sections := map[string]bool{}
+	for c := app.current; c != nil; c = c.parent {
+		sections[c.configSection()] = true
+		for _, f := range c.allFlags {
+			if f != app.configFlag {
+				known[c.configKey(f)] = true
+			}
+		}
+	}
+	keys := []string{}
+	for key := range config.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		section := ""
+		i := strings.LastIndex(key, ".")
+		if i >= 0 {
+			section = key[:i]
+		}
+		if sections[section] && !known[key] {
+			app.Error(config.path + ": unrecognized key " + key)
+		}
+	}
+}
+
+// configSection is the config file section for flags declared on this
+// command.
+func (c *Command) configSection() string {
+	if c.parent == nil {
+		return ""
+	}
+	parent := c.parent.configSection()
+	if parent == "" {
+		return c.Name
+	}
+	return parent + "." + c.Name
+}
+
+func (c *Command) configKey(f *Flag) string {
+	section := c.configSection()
+	if section == "" {
+		return f.Long
+	}
+	return section + "." + f.Long
+}
+
+// owner finds the command that declares the flag.
+func (c *Command) owner(f *Flag) *Command {
+	for current := c; current != nil; current = current.parent {
+		for _, other := range current.allFlags {
+			if other == f {
+				return current
+			}
+		}
+	}
+	return nil
+}
+
+// notifyConfig supplies the flag's value from the config file, returning false
+// if the file doesn't mention the flag.
+func (app *App) notifyConfig(f *Flag, config *configValues) bool {
+	if config == nil || f.Long == "" {
+		return false
+	}
+	key := app.current.owner(f).configKey(f)
+	values, ok := config.values[key]
+	if !ok {
+		return false
+	}
+	log := &prefixLogger{prefix: config.path + ": " + key + ": ", log: app}
+	max := f.MaxUses()
+	if max != Unlimited && len(values) > max {
+		// Counted as a single use so that the flag isn't also reported as
+		// missing or overused without the file and key.
+		f.useCount++
+		log.Error(fmt.Sprintf("%d values given, at most %d allowed", len(values), max))
+		return true
+	}
+	for _, value := range values {
+		f.useCount++
+		if f.Value != nil {
+			f.Value.Notify(value, log)
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Error(fmt.Sprintf("%#v is not true or false", value))
+		} else if enabled {
+			f.Call()
+		}
+	}
+	return true
+}
+
+func readConfig(path string) (*configValues, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string][]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSONConfig(data)
+	case ".toml":
+		values, err = parseTOMLConfig(data)
+	case ".ini", ".cfg", ".conf":
+		values, err = parseINIConfig(data)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file format, expected .json, .toml or .ini", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return &configValues{path: path, values: values}, nil
+}
+
+func joinConfigKey(section string, key string) string {
+	if section == "" {
+		return key
+	}
+	return section + "." + key
+}
+
+func parseJSONConfig(data []byte) (map[string][]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	err := decoder.Decode(&root)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]string{}
+	err = flattenJSON("", root, values)
+	return values, err
+}
+
+func flattenJSON(section string, object map[string]interface{}, values map[string][]string) error {
+	for key, value := range object {
+		key = joinConfigKey(section, key)
+		switch value := value.(type) {
+		case map[string]interface{}:
+			err := flattenJSON(key, value, values)
+			if err != nil {
+				return err
+			}
+		case []interface{}:
+			for _, element := range value {
+				text, ok := jsonScalar(element)
+				if !ok {
+					return fmt.Errorf("%s: arrays may only contain strings, numbers and booleans", key)
+				}
+				values[key] = append(values[key], text)
+			}
+		default:
+			text, ok := jsonScalar(value)
+			if !ok {
+				return fmt.Errorf("%s: unsupported value", key)
+			}
+			values[key] = append(values[key], text)
+		}
+	}
+	return nil
+}
+
+func jsonScalar(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		return "", false
+	}
+}
+
+// parseTOMLConfig understands the subset of TOML needed for flag values:
+// tables, and keys set to strings, numbers, booleans or single line arrays of
+// them.
+func parseTOMLConfig(data []byte) (map[string][]string, error) {
+	values := map[string][]string{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[[") {
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", line)
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: expected ]", line)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+		equals := strings.Index(text, "=")
+		if equals < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		key := strings.TrimSpace(text[:equals])
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		}
+		parsed, err := parseTOMLValue(strings.TrimSpace(text[equals+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %s", line, key, err)
+		}
+		key = joinConfigKey(section, key)
+		values[key] = append(values[key], parsed...)
+	}
+	return values, scanner.Err()
+}
+
+// stripTOMLComment removes a trailing comment, taking care not to mistake a
+// "#" inside a string for one.
+func stripTOMLComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseTOMLValue(text string) ([]string, error) {
+	if !strings.HasPrefix(text, "[") {
+		value, err := parseTOMLScalar(text)
+		if err != nil {
+			return nil, err
+		}
+		return []string{value}, nil
+	}
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("arrays must be written on one line")
+	}
+	values := []string{}
+	for _, element := range splitTOMLArray(text[1 : len(text)-1]) {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+		value, err := parseTOMLScalar(element)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// splitTOMLArray splits the inside of an array on commas that are not inside
+// strings.
+func splitTOMLArray(text string) []string {
+	elements := []string{}
+	var quote rune
+	escaped := false
+	start := 0
+	for i, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			elements = append(elements, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(elements, text[start:])
+}
+
+func parseTOMLScalar(text string) (string, error) {
+	switch {
+	case strings.HasPrefix(text, "\""):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return "", fmt.Errorf("invalid string %s", text)
+		}
+		return text[1 : len(text)-1], nil
+	case text == "true" || text == "false":
+		return text, nil
+	default:
+		number := strings.ReplaceAll(text, "_", "")
+		_, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			_, err = strconv.ParseInt(number, 0, 64)
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid value %s", text)
+		}
+		return number, nil
+	}
+}
+
+// parseINIConfig reads "key = value" (or "key: value") lines grouped into
+// [sections].  Repeating a key gives a flag several values.
+func parseINIConfig(data []byte) (map[string][]string, error) {
+	values := map[string][]string{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, ";") || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: expected ]", line)
+			}
+			section = strings.Join(strings.Fields(text[1:len(text)-1]), ".")
+			continue
+		}
+		split := strings.IndexAny(text, "=:")
+		if split < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		key := joinConfigKey(section, strings.TrimSpace(text[:split]))
+		value := strings.TrimSpace(text[split+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = append(values[key], value)
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,151 @@
+package cmdline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type configTestFlags struct {
+	jobs    int32
+	name    string
+	tags    []string
+	verbose bool
+	message string
+}
+
+func makeConfigApp(flags *configTestFlags, searchPaths ...string) *App {
+	app := MakeApp("foo")
+	app.EnvPrefix("FOO")
+	app.ConfigFile(searchPaths...)
+	app.Flags([]*Flag{
+		{Long: "jobs", Value: Int32.Set(&flags.jobs), Default: "32"},
+		{Long: "name", Value: String.Set(&flags.name), Min: 1},
+		{Long: "tag", Value: String.Append(&flags.tags)},
+		{Long: "verbose", Call: SetTrue(&flags.verbose)},
+	})
+	commit := app.Subcommand("commit")
+	commit.Flags([]*Flag{
+		{Long: "message", Value: String.Set(&flags.message)},
+	})
+	commit.Action = func() {}
+	app.Action = func() {}
+	return app
+}
+
+func writeConfig(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	return path
+}
+
+func TestConfigJSON(t *testing.T) {
+	path := writeConfig(t, "foo.json", `{
+		"jobs": 4,
+		"name": "json",
+		"tag": ["a", "b"],
+		"verbose": true,
+		"commit": {"message": "hello"}
+	}`)
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags)
+	_, err := app.Parse([]string{"--config", path, "commit"})
+	assert.NoError(t, err)
+	assert.Equal(t, &configTestFlags{jobs: 4, name: "json", tags: []string{"a", "b"}, verbose: true, message: "hello"}, flags)
+}
+
+func TestConfigTOML(t *testing.T) {
+	path := writeConfig(t, "foo.toml", `
+# A comment.
+jobs = 1_000
+name = "toml # not a comment" # a comment
+tag = ['a', "b,c"]
+
+[commit]
+message = "hi"
+`)
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags, "/does/not/exist.toml", path)
+	_, err := app.Parse([]string{"commit"})
+	assert.NoError(t, err)
+	assert.Equal(t, &configTestFlags{jobs: 1000, name: "toml # not a comment", tags: []string{"a", "b,c"}, message: "hi"}, flags)
+}
+
+func TestConfigINI(t *testing.T) {
+	path := writeConfig(t, "foo.ini", `
+; A comment.
+name = "ini"
+tag = a
+tag = b
+verbose: false
+`)
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags, path)
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, &configTestFlags{jobs: 32, name: "ini", tags: []string{"a", "b"}}, flags)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "foo.json", `{"jobs": 4, "name": "config"}`)
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags, path)
+	t.Setenv("FOO_NAME", "env")
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "env", flags.name)
+	assert.Equal(t, int32(4), flags.jobs)
+	_, err = app.Parse([]string{"--name", "flag", "--jobs", "8"})
+	assert.NoError(t, err)
+	assert.Equal(t, "flag", flags.name)
+	assert.Equal(t, int32(8), flags.jobs)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	path := writeConfig(t, "foo.json", `{"name": "config"}`)
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags)
+	t.Setenv("FOO_CONFIG", path)
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "config", flags.name)
+}
+
+func TestConfigErrors(t *testing.T) {
+	path := writeConfig(t, "foo.toml", "jobs = \"many\"\nname = \"x\"\nbogus = 1\n[commit]\nbogus = 2\n")
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags)
+	_, err := app.Parse([]string{"--config", path})
+	assert.Equal(t, []string{
+		path + ": unrecognized key bogus",
+		path + `: jobs: "many" cannot be converted into an int32`,
+	}, err.(*UsageError).Messages)
+}
+
+func TestConfigTooManyValues(t *testing.T) {
+	path := writeConfig(t, "foo.ini", "name = a\nname = b\ntag = x\ntag = y\n")
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags)
+	_, err := app.Parse([]string{"--config", path})
+	assert.Equal(t, []string{
+		path + ": name: 2 values given, at most 1 allowed",
+	}, err.(*UsageError).Messages)
+
+	path = writeConfig(t, "foo.json", `{"name": "a", "jobs": [1, 2]}`)
+	_, err = app.Parse([]string{"--config", path})
+	assert.Equal(t, []string{
+		path + ": jobs: 2 values given, at most 1 allowed",
+	}, err.(*UsageError).Messages)
+}
+
+func TestConfigSyntaxError(t *testing.T) {
+	path := writeConfig(t, "foo.toml", "name = \"x\"\njobs\n")
+	flags := &configTestFlags{}
+	app := makeConfigApp(flags)
+	_, err := app.Parse([]string{"--config", path})
+	assert.Equal(t, []string{
+		path + ": line 2: expected key = value",
+		"--name is required",
+	}, err.(*UsageError).Messages)
+}
@@ -0,0 +1,197 @@
+package cmdline
+
+import (
+	"io"
+	"strings"
+)
+
+type constraintKind int
+
+const (
+	exclusiveConstraint constraintKind = iota
+	atLeastOneConstraint
+	requiresConstraint
+	conflictsConstraint
+)
+
+// constraint relates flags to each other.  For requires and conflicts, flag
+// is the subject and flags are the flags it requires or conflicts with.
+type constraint struct {
+	kind  constraintKind
+	flag  *Flag
+	flags []*Flag
+}
+
+func flagRef(f *Flag) string {
+	return "--" + f.Long
+}
+
+func flagRefs(flags []*Flag) string {
+	refs := []string{}
+	for _, f := range flags {
+		refs = append(refs, flagRef(f))
+	}
+	return strings.Join(refs, ", ")
+}
+
+func (c *Command) constraintFlags(names []string) []*Flag {
+	flags := []*Flag{}
+	for _, name := range names {
+		f := c.lookupLong(name)
+		if f == nil {
+			panic("Constraint on " + c.Path() + " refers to unknown flag --" + name)
+		}
+		flags = append(flags, f)
+	}
+	return flags
+}
+
+// Exclusive allows at most one of the named flags to be used.
+func (c *Command) Exclusive(names ...string) {
+	if len(names) < 2 {
+		panic("Exclusive needs at least two flags.")
+	}
+	c.constraints = append(c.constraints, &constraint{kind: exclusiveConstraint, flags: c.constraintFlags(names)})
+}
+
+// AtLeastOne requires that one or more of the named flags is used.
+func (c *Command) AtLeastOne(names ...string) {
+	if len(names) < 1 {
+		panic("AtLeastOne needs at least one flag.")
+	}
+	c.constraints = append(c.constraints, &constraint{kind: atLeastOneConstraint, flags: c.constraintFlags(names)})
+}
+
+// Requires makes using flag name depend on all of the required flags also
+// being used.
+func (c *Command) Requires(name string, required ...string) {
+	c.constraints = append(c.constraints, &constraint{
+		kind:  requiresConstraint,
+		flag:  c.constraintFlags([]string{name})[0],
+		flags: c.constraintFlags(required),
+	})
+}
+
+// Conflicts forbids using flag name together with any of the others.
+func (c *Command) Conflicts(name string, others ...string) {
+	c.constraints = append(c.constraints, &constraint{
+		kind:  conflictsConstraint,
+		flag:  c.constraintFlags([]string{name})[0],
+		flags: c.constraintFlags(others),
+	})
+}
+
+// activeConstraints lists the constraints of this command and its ancestors.
+func (c *Command) activeConstraints() []*constraint {
+	constraints := []*constraint{}
+	if c.parent != nil {
+		constraints = c.parent.activeConstraints()
+	}
+	return append(constraints, c.constraints...)
+}
+
+func usedFlags(flags []*Flag) []*Flag {
+	used := []*Flag{}
+	for _, f := range flags {
+		if f.useCount > 0 {
+			used = append(used, f)
+		}
+	}
+	return used
+}
+
+// check reports a violation of the constraint.
+func (c *constraint) check(log Logger) {
+	switch c.kind {
+	case exclusiveConstraint:
+		used := usedFlags(c.flags)
+		if len(used) > 1 {
+			log.Error(flagRefs(used) + " cannot be used together")
+		}
+	case atLeastOneConstraint:
+		if len(usedFlags(c.flags)) == 0 {
+			log.Error("one of " + flagRefs(c.flags) + " is required")
+		}
+	case requiresConstraint:
+		if c.flag.useCount == 0 {
+			return
+		}
+		for _, f := range c.flags {
+			if f.useCount == 0 {
+				log.Error(flagRef(c.flag) + " requires " + flagRef(f))
+			}
+		}
+	case conflictsConstraint:
+		if c.flag.useCount == 0 {
+			return
+		}
+		for _, f := range usedFlags(c.flags) {
+			log.Error(flagRef(c.flag) + " cannot be used with " + flagRef(f))
+		}
+	}
+}
+
+// excludes is true if the constraint means f can no longer be used, given the
+// flags used on the command line so far.
+func (c *constraint) excludes(f *Flag) bool {
+	switch c.kind {
+	case exclusiveConstraint:
+		member := false
+		for _, other := range c.flags {
+			member = member || other == f
+		}
+		if !member {
+			return false
+		}
+		for _, other := range c.flags {
+			if other != f && other.givenOnCommandLine() {
+				return true
+			}
+		}
+	case conflictsConstraint:
+		for _, other := range c.flags {
+			if f == c.flag && other.givenOnCommandLine() || f == other && c.flag.givenOnCommandLine() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *constraint) describe() string {
+	switch c.kind {
+	case exclusiveConstraint:
+		return flagRefs(c.flags) + " are mutually exclusive"
+	case atLeastOneConstraint:
+		return "one of " + flagRefs(c.flags) + " is required"
+	case requiresConstraint:
+		return flagRef(c.flag) + " requires " + flagRefs(c.flags)
+	default:
+		return flagRef(c.flag) + " conflicts with " + flagRefs(c.flags)
+	}
+}
+
+// excluded is true if a constraint forbids using f alongside the flags that
+// have been given on the command line.
+func (c *Command) excluded(f *Flag) bool {
+	for _, constraint := range c.activeConstraints() {
+		if constraint.excludes(f) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Command) writeConstraints(out io.Writer) {
+	constraints := c.activeConstraints()
+	if len(constraints) == 0 {
+		return
+	}
+	io.WriteString(out, "\n")
+	io.WriteString(out, "Constraints:\n")
+	rows := []helpRow{}
+	for _, constraint := range constraints {
+		rows = append(rows, helpRow{left: constraint.describe()})
+	}
+	writeColumns(out, rows, terminalWidth())
+}
@@ -0,0 +1,123 @@
+package cmdline
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeConstraintApp() *App {
+	app := MakeApp("export")
+	app.Flags([]*Flag{
+		{Long: "json", Help: "Write JSON.", Call: func() {}},
+		{Long: "yaml", Help: "Write YAML.", Call: func() {}},
+		{Long: "tls-cert", Value: String.Call(func(string) {})},
+		{Long: "tls-key", Value: String.Call(func(string) {})},
+		{Long: "quiet", Short: 'q', Call: func() {}},
+		{Long: "verbose", Short: 'v', Call: func() {}},
+	})
+	app.Exclusive("json", "yaml")
+	app.AtLeastOne("json", "yaml")
+	app.Requires("tls-key", "tls-cert")
+	app.Conflicts("quiet", "verbose")
+	return app
+}
+
+func TestConstraints(t *testing.T) {
+	app := makeConstraintApp()
+	_, err := app.Parse([]string{"--json", "--tls-key", "k", "--tls-cert", "c"})
+	assert.NoError(t, err)
+	_, err = app.Parse([]string{"--json", "--yaml"})
+	assert.EqualError(t, err, "--json, --yaml cannot be used together")
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "one of --json, --yaml is required")
+	_, err = app.Parse([]string{"--yaml", "--tls-key", "k"})
+	assert.EqualError(t, err, "--tls-key requires --tls-cert")
+	_, err = app.Parse([]string{"--yaml", "-qv"})
+	assert.EqualError(t, err, "--quiet cannot be used with --verbose")
+}
+
+func TestConstraintsEnv(t *testing.T) {
+	app := makeConstraintApp()
+	app.EnvPrefix("EXPORT")
+	t.Setenv("EXPORT_TLS_CERT", "c")
+	_, err := app.Parse([]string{"--json", "--tls-key", "k"})
+	assert.NoError(t, err)
+}
+
+func TestConstraintsConfig(t *testing.T) {
+	var json, yaml, quiet, verbose bool
+	app := MakeApp("export")
+	app.Flags([]*Flag{
+		{Long: "json", Call: SetTrue(&json)},
+		{Long: "yaml", Call: SetTrue(&yaml)},
+		{Long: "quiet", Call: SetTrue(&quiet)},
+		{Long: "verbose", Call: SetTrue(&verbose)},
+	})
+	app.Exclusive("json", "yaml")
+	app.Conflicts("quiet", "verbose")
+	path := filepath.Join(t.TempDir(), "export.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"json": true, "verbose": true}`), 0644))
+	app.ConfigFile(path)
+
+	// The command line wins over the config file.
+	_, err := app.Parse([]string{"--yaml", "--quiet"})
+	assert.NoError(t, err)
+	assert.Equal(t, false, json)
+	assert.Equal(t, true, yaml)
+	assert.Equal(t, false, verbose)
+	assert.Equal(t, true, quiet)
+
+	_, err = app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, true, json)
+	assert.Equal(t, true, verbose)
+
+	// Conflicts within the config file are still reported.
+	assert.NoError(t, os.WriteFile(path, []byte(`{"json": true, "yaml": true}`), 0644))
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "--json, --yaml cannot be used together")
+}
+
+func TestConstraintsUnknownFlag(t *testing.T) {
+	app := makeConstraintApp()
+	assert.Panics(t, func() {
+		app.Exclusive("json", "xml")
+	})
+}
+
+func TestConstraintsSubcommand(t *testing.T) {
+	app := makeConstraintApp()
+	app.Action = func() {}
+	sub := app.Subcommand("run")
+	sub.Flags([]*Flag{{Long: "dry-run", Call: func() {}}})
+	sub.Conflicts("dry-run", "json")
+	_, err := app.Parse([]string{"run", "--json", "--dry-run"})
+	assert.EqualError(t, err, "--dry-run cannot be used with --json")
+	_, err = app.Parse([]string{"--json"})
+	assert.NoError(t, err)
+}
+
+func TestConstraintsComplete(t *testing.T) {
+	app := makeConstraintApp()
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--json", "--"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--tls-cert\n--tls-key\n--quiet\n--verbose\n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "", "-v", "-"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--json\n--yaml\n--tls-cert\n--tls-key\n", result.Output)
+}
+
+func TestConstraintsHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	app := makeConstraintApp()
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Contains(t, b.String(), "\nConstraints:\n"+
+		"    --json, --yaml are mutually exclusive\n"+
+		"    one of --json, --yaml is required\n"+
+		"    --tls-key requires --tls-cert\n"+
+		"    --quiet conflicts with --verbose\n")
+}
@@ -0,0 +1,248 @@
+package cmdline
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultHelpWidth = 80
+	helpIndent       = "    "
+	helpGap          = "   "
+	// Left columns wider than this push their help text onto the next line.
+	maxHelpColumn = 32
+	// Don't squeeze help text into anything narrower than this.
+	minHelpText = 20
+)
+
+// terminalWidth guesses the width of the terminal help will be shown on.
+func terminalWidth() int {
+	columns, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || columns <= 0 {
+		return defaultHelpWidth
+	}
+	return columns
+}
+
+// wrapText breaks text into lines no longer than width, preserving explicit
+// line breaks.  Words longer than width are not broken.
+func wrapText(text string, width int) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line == "" {
+				line = word
+			} else if len(line)+1+len(word) <= width {
+				line += " " + word
+			} else {
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func writeParagraphs(out io.Writer, text string, width int) {
+	for _, line := range wrapText(text, width) {
+		io.WriteString(out, line)
+		io.WriteString(out, "\n")
+	}
+}
+
+type helpRow struct {
+	left  string
+	right string
+}
+
+// writeColumns writes rows as two aligned columns, wrapping the right column
+// to fit within width.
+func writeColumns(out io.Writer, rows []helpRow, width int) {
+	column := 0
+	for _, row := range rows {
+		if len(row.left) > column && len(row.left) <= maxHelpColumn {
+			column = len(row.left)
+		}
+	}
+	textColumn := len(helpIndent) + column + len(helpGap)
+	textWidth := width - textColumn
+	if textWidth < minHelpText {
+		textWidth = minHelpText
+	}
+	padding := strings.Repeat(" ", textColumn)
+
+	for _, row := range rows {
+		io.WriteString(out, helpIndent)
+		io.WriteString(out, row.left)
+		if row.right == "" {
+			io.WriteString(out, "\n")
+			continue
+		}
+		lines := wrapText(row.right, textWidth)
+		if len(row.left) > column {
+			io.WriteString(out, "\n")
+			io.WriteString(out, padding)
+		} else {
+			io.WriteString(out, strings.Repeat(" ", column-len(row.left)))
+			io.WriteString(out, helpGap)
+		}
+		for i, line := range lines {
+			if i > 0 {
+				io.WriteString(out, padding)
+			}
+			io.WriteString(out, line)
+			io.WriteString(out, "\n")
+		}
+	}
+}
+
+// joinHelp combines help text with bracketed annotations.
+func joinHelp(help string, annotations []string) string {
+	parts := []string{}
+	if help != "" {
+		parts = append(parts, help)
+	}
+	for _, a := range annotations {
+		parts = append(parts, "["+a+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// flagNames is the flag's name, with the --no- form folded in for negatable
+// flags.
+func flagNames(f *Flag) string {
+	if f.Negatable {
+		return strings.Replace(f.Name(), "--", "--[no-]", 1)
+	}
+	return f.Name()
+}
+
+// flagUsage shows how the flag is written: its names and the type of value it
+// takes.
+func flagUsage(f *Flag) string {
+	usage := flagNames(f)
+	if f.ValueOptional || f.Value != nil && isCounter(f.Value) {
+		usage += "[=" + f.Value.TypeName() + "]"
+	} else if f.longMode() == requiredValue {
+		usage += " " + f.Value.TypeName()
+	}
+	if f.longMode() != noValue && isRepeatable(f.Value) {
+		usage += "..."
+	}
+	return usage
+}
+
+// flagAnnotations lists the environment variable, default and required marker
+// shown after the flag's help.
+func flagAnnotations(c *Command, f *Flag) []string {
+	annotations := []string{}
+	env := c.envName(f)
+	if env != "" {
+		annotations = append(annotations, "$"+env)
+	}
+	if f.Default != "" {
+		annotations = append(annotations, "default="+formatDefault(f.Value, f.Default))
+	}
+	if f.Min > 0 {
+		annotations = append(annotations, "required")
+	}
+	return annotations
+}
+
+func flagHelpRow(c *Command, f *Flag) helpRow {
+	return helpRow{left: flagUsage(f), right: joinHelp(f.Help, flagAnnotations(c, f))}
+}
+
+func argumentUsage(name string, a *Argument) string {
+	if a.Value != nil {
+		return name + " " + a.Value.TypeName()
+	}
+	return name
+}
+
+func argumentHelpRow(name string, a *Argument) helpRow {
+	return helpRow{left: argumentUsage(name, a), right: a.Help}
+}
+
+// summary is the first line of the command's description.
+func (c *Command) summary() string {
+	return strings.SplitN(strings.TrimSpace(c.Description), "\n", 2)[0]
+}
+
+// usageSuffix is what follows the command's path in its usage line.
+func (c *Command) usageSuffix() string {
+	suffix := ""
+	if len(c.visibleFlags()) > 0 {
+		suffix += " [<flags>]"
+	}
+	if len(c.subcommands) > 0 {
+		suffix += " <command>"
+	}
+	for _, a := range c.requiredArguments {
+		suffix += " <" + a.Name + ">"
+	}
+	if c.excessArguments != nil {
+		suffix += " [<" + c.excessArguments.Name + ">...]"
+	}
+	return suffix
+}
+
+func (c *Command) WriteHelp(out io.Writer) {
+	width := terminalWidth()
+	flags := c.visibleFlags()
+
+	io.WriteString(out, "usage: ")
+	io.WriteString(out, c.Path())
+	io.WriteString(out, c.usageSuffix())
+	io.WriteString(out, "\n")
+
+	if c.Description != "" {
+		io.WriteString(out, "\n")
+		writeParagraphs(out, c.Description, width)
+	}
+
+	if len(flags) > 0 {
+		io.WriteString(out, "\n")
+		io.WriteString(out, "Flags:\n")
+		rows := []helpRow{}
+		for _, f := range flags {
+			rows = append(rows, flagHelpRow(c, f))
+		}
+		writeColumns(out, rows, width)
+	}
+
+	if len(c.requiredArguments) > 0 || c.excessArguments != nil {
+		io.WriteString(out, "\n")
+		io.WriteString(out, "Args:\n")
+		rows := []helpRow{}
+		for _, a := range c.requiredArguments {
+			rows = append(rows, argumentHelpRow("<"+a.Name+">", a))
+		}
+		if c.excessArguments != nil {
+			a := c.excessArguments
+			rows = append(rows, argumentHelpRow("<"+a.Name+">...", a))
+		}
+		writeColumns(out, rows, width)
+	}
+
+	if len(c.subcommands) > 0 {
+		io.WriteString(out, "\n")
+		io.WriteString(out, "Commands:\n")
+		rows := []helpRow{}
+		for _, sub := range c.subcommands {
+			rows = append(rows, helpRow{left: sub.Name, right: sub.summary()})
+		}
+		writeColumns(out, rows, width)
+	}
+
+	c.writeConstraints(out)
+
+	if c.Epilogue != "" {
+		io.WriteString(out, "\n")
+		writeParagraphs(out, c.Epilogue, width)
+	}
+}
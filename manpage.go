@@ -0,0 +1,150 @@
+package cmdline
+
+import (
+	"io"
+	"strings"
+)
+
+// roffEscape makes text safe to use as roff input.
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\e")
+	text = strings.ReplaceAll(text, "-", "\\-")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		// A leading . or ' would be taken as a request.
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeManParagraphs writes text, keeping blank lines as paragraph breaks.
+func writeManParagraphs(out io.Writer, text string) {
+	for i, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			io.WriteString(out, ".PP\n")
+		}
+		io.WriteString(out, roffEscape(strings.TrimSpace(paragraph)))
+		io.WriteString(out, "\n")
+	}
+}
+
+// writeManItems writes a tagged paragraph for each row.
+func writeManItems(out io.Writer, rows []helpRow) {
+	for _, row := range rows {
+		io.WriteString(out, ".TP\n")
+		io.WriteString(out, "\\fB"+roffEscape(row.left)+"\\fR\n")
+		if row.right != "" {
+			io.WriteString(out, roffEscape(row.right))
+			io.WriteString(out, "\n")
+		}
+	}
+}
+
+// writeManCommand documents the flags, arguments and constraints declared by
+// the command itself.  Inherited flags are documented with the command that
+// declares them.  heading writes the title of each part.
+func (c *Command) writeManCommand(out io.Writer, heading func(title string)) {
+	rows := []helpRow{}
+	for _, f := range c.allFlags {
+		rows = append(rows, flagHelpRow(c, f))
+	}
+	if len(rows) > 0 {
+		heading("Options")
+		writeManItems(out, rows)
+	}
+
+	rows = []helpRow{}
+	for _, a := range c.requiredArguments {
+		rows = append(rows, argumentHelpRow("<"+a.Name+">", a))
+	}
+	if c.excessArguments != nil {
+		rows = append(rows, argumentHelpRow("<"+c.excessArguments.Name+">...", c.excessArguments))
+	}
+	if len(rows) > 0 {
+		heading("Arguments")
+		writeManItems(out, rows)
+	}
+
+	if len(c.constraints) > 0 {
+		heading("Constraints")
+		for _, constraint := range c.constraints {
+			io.WriteString(out, ".IP \\(bu 2\n")
+			io.WriteString(out, roffEscape(constraint.describe()))
+			io.WriteString(out, "\n")
+		}
+	}
+}
+
+// WriteManPage writes a man(7) page documenting the app and all of its
+// subcommands.
+func (app *App) WriteManPage(out io.Writer) {
+	title := strings.ToUpper(app.Name)
+	io.WriteString(out, ".TH "+roffEscape(title)+" 1 \"\" \""+roffEscape(strings.TrimSpace(app.Name+" "+app.version))+"\"\n")
+
+	io.WriteString(out, ".SH NAME\n")
+	io.WriteString(out, roffEscape(app.Name))
+	if app.summary() != "" {
+		io.WriteString(out, " \\- "+roffEscape(app.summary()))
+	}
+	io.WriteString(out, "\n")
+
+	io.WriteString(out, ".SH SYNOPSIS\n")
+	app.Command.walk(func(c *Command) {
+		if c != app.Command && c.Action == nil && len(c.subcommands) > 0 {
+			return
+		}
+		io.WriteString(out, ".B "+roffEscape(c.Path())+"\n")
+		io.WriteString(out, roffEscape(strings.TrimSpace(c.usageSuffix()))+"\n")
+		io.WriteString(out, ".br\n")
+	})
+
+	if app.Description != "" {
+		io.WriteString(out, ".SH DESCRIPTION\n")
+		writeManParagraphs(out, app.Description)
+	}
+
+	app.Command.writeManCommand(out, func(title string) {
+		io.WriteString(out, ".SH "+strings.ToUpper(title)+"\n")
+	})
+
+	if len(app.subcommands) > 0 {
+		io.WriteString(out, ".SH COMMANDS\n")
+		app.Command.walk(func(c *Command) {
+			if c == app.Command {
+				return
+			}
+			io.WriteString(out, ".SS \""+roffEscape(c.Path())+"\"\n")
+			if c.Description != "" {
+				writeManParagraphs(out, c.Description)
+			}
+			c.writeManCommand(out, func(title string) {
+				io.WriteString(out, ".PP\n\\fB"+title+":\\fR\n")
+			})
+			if c.Epilogue != "" {
+				io.WriteString(out, ".PP\n")
+				writeManParagraphs(out, c.Epilogue)
+			}
+		})
+	}
+
+	env := []helpRow{}
+	app.Command.walk(func(c *Command) {
+		for _, f := range c.allFlags {
+			name := c.envName(f)
+			if name != "" {
+				env = append(env, helpRow{left: name, right: "Used for " + f.Name() + " when it is not given on the command line."})
+			}
+		}
+	})
+	if len(env) > 0 {
+		io.WriteString(out, ".SH ENVIRONMENT\n")
+		writeManItems(out, env)
+	}
+
+	if app.Epilogue != "" {
+		io.WriteString(out, ".SH NOTES\n")
+		writeManParagraphs(out, app.Epilogue)
+	}
+}
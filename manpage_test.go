@@ -0,0 +1,75 @@
+package cmdline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoffEscape(t *testing.T) {
+	assert.Equal(t, "\\-\\-jobs", roffEscape("--jobs"))
+	assert.Equal(t, "a \\e b\n\\&.start\n\\&'quote", roffEscape("a \\ b\n.start\n'quote"))
+}
+
+func TestManPage(t *testing.T) {
+	var jobs int32
+	var message string
+	app := MakeApp("tool")
+	app.Description = "Do things.\n\nIn parallel."
+	app.EnvPrefix("TOOL")
+	app.Flags([]*Flag{
+		{Long: "jobs", Short: 'j', Help: "Run this many jobs.", Value: Int32.Set(&jobs), Default: "4"},
+	})
+	app.Version("1.2")
+	commit := app.Subcommand("commit")
+	commit.Description = "Record changes."
+	commit.Flags([]*Flag{
+		{Long: "message", Short: 'm', Value: String.Set(&message), Min: 1},
+	})
+	commit.ExcessArguments(&Argument{Name: "file", Help: "Files to commit.", Value: String.Call(func(string) {})})
+
+	result, err := app.Parse([]string{"--generate-man-page"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Done)
+	assert.Equal(t, `.TH TOOL 1 "" "tool 1.2"
+.SH NAME
+tool \- Do things.
+.SH SYNOPSIS
+.B tool
+[<flags>] <command>
+.br
+.B tool commit
+[<flags>] [<file>...]
+.br
+.SH DESCRIPTION
+Do things.
+.PP
+In parallel.
+.SH OPTIONS
+.TP
+\fB\-j/\-\-jobs int32\fR
+Run this many jobs. [$TOOL_JOBS] [default=4]
+.TP
+\fB\-\-version\fR
+Show the version and exit.
+.SH COMMANDS
+.SS "tool commit"
+Record changes.
+.PP
+\fBOptions:\fR
+.TP
+\fB\-m/\-\-message string\fR
+[$TOOL_MESSAGE] [required]
+.PP
+\fBArguments:\fR
+.TP
+\fB<file>... string\fR
+Files to commit.
+.SH ENVIRONMENT
+.TP
+\fBTOOL_JOBS\fR
+Used for \-j/\-\-jobs when it is not given on the command line.
+.TP
+\fBTOOL_MESSAGE\fR
+Used for \-m/\-\-message when it is not given on the command line.
+`, result.Output)
+}
@@ -1,16 +1,31 @@
 package cmdline
 
+// valueMode describes whether a flag takes a value.
+type valueMode int
+
+const (
+	noValue valueMode = iota
+	// The value may be attached with "--flag=value" or "-fvalue", or be the
+	// next argument.
+	requiredValue
+	// The value must be attached.  Without one the flag is notified as if it
+	// took no value.
+	optionalValue
+)
+
 type parseObserver interface {
 	Logger
 
-	longFlagInfo(name string) (bool, bool)
-	shortFlagInfo(name rune) (bool, bool)
+	longFlagInfo(name string) (bool, valueMode)
+	shortFlagInfo(name rune) (bool, valueMode)
+	unrecognizedLongFlag(name string)
 
 	notifyLongFlag(name string) bool
 	notifyLongFlagValue(name string, value string) bool
 	notifyShortFlag(name rune) bool
 	notifyShortFlagValue(name rune, value string) bool
 	notifyArg(value string) bool
+	notifyCommand(name string) bool
 
 	completeLongFlag(prefix string, c CompletionObserver)
 	completeLongFlagValue(name string, value string, c CompletionObserver)
@@ -20,11 +35,23 @@ type parseObserver interface {
 
 	completeArg(prefix string, c CompletionObserver)
 	acceptingArgs() bool
+
+	completeCommand(prefix string, c CompletionObserver)
+	acceptingCommand() bool
 }
 
 type CompletionObserver interface {
 	PartialCompletion(completion string)
 	FinalCompletion(completion string)
+	// DescribedCompletion offers a completion along with a description that
+	// shells able to display one will show next to it.
+	DescribedCompletion(completion string, description string, partial bool)
+}
+
+type candidate struct {
+	text        string
+	description string
+	partial     bool
 }
 
 type parser struct {
@@ -35,6 +62,7 @@ type parser struct {
 
 	prependCompletion string
 	completions       []string
+	candidates        []candidate
 	isPartial         bool
 }
 
@@ -59,12 +87,20 @@ func (p *parser) status(ok bool) {
 }
 
 func (p *parser) FinalCompletion(completion string) {
-	p.completions = append(p.completions, p.prependCompletion+completion)
+	p.DescribedCompletion(completion, "", false)
 }
 
 func (p *parser) PartialCompletion(completion string) {
-	p.completions = append(p.completions, p.prependCompletion+completion)
-	p.isPartial = true
+	p.DescribedCompletion(completion, "", true)
+}
+
+func (p *parser) DescribedCompletion(completion string, description string, partial bool) {
+	text := p.prependCompletion + completion
+	p.completions = append(p.completions, text)
+	p.candidates = append(p.candidates, candidate{text: text, description: description, partial: partial})
+	if partial {
+		p.isPartial = true
+	}
 }
 
 func handleLongFlagValue(p *parser, name string, value string, observer parseObserver) {
@@ -86,13 +122,13 @@ func parseLongFlag(p *parser, arg []rune, observer parseObserver) {
 		c++
 	}
 	name := string(arg[:c])
-	exists, takesValue := observer.longFlagInfo(name)
+	exists, mode := observer.longFlagInfo(name)
 
 	if equals {
 		if !exists {
-			observer.Error("unrecognized flag --" + name)
+			observer.unrecognizedLongFlag(name)
 			p.status(false)
-		} else if takesValue {
+		} else if mode != noValue {
 			value := string(arg[c+1:])
 			p.prependCompletion = "--" + string(arg[:c+1])
 			//p.prependCompletion = ""
@@ -105,9 +141,9 @@ func parseLongFlag(p *parser, arg []rune, observer parseObserver) {
 		if p.shouldComplete() {
 			completeLongFlag(p, name, observer)
 		} else if !exists {
-			observer.Error("unrecognized flag --" + name)
+			observer.unrecognizedLongFlag(name)
 			p.status(false)
-		} else if takesValue {
+		} else if mode == requiredValue {
 			if p.hasNext() {
 				value := p.getNext()
 				p.prependCompletion = ""
@@ -133,11 +169,24 @@ func handleShortFlagValue(p *parser, name rune, value string, observer parseObse
 func parseShortFlag(p *parser, arg []rune, observer parseObserver) {
 	for c := 0; c < len(arg) && p.parseOK; c++ {
 		name := arg[c]
-		exists, takesValue := observer.shortFlagInfo(name)
+		exists, mode := observer.shortFlagInfo(name)
 		if !exists {
 			observer.Error("unrecognized flag -" + string(name))
 			p.status(false)
-		} else if takesValue {
+		} else if mode == optionalValue {
+			// The rest of the argument, if any, is the value.
+			c++
+			if c < len(arg) {
+				value := string(arg[c:])
+				handleShortFlagValue(p, name, value, observer)
+			} else if p.shouldComplete() {
+				p.prependCompletion = ""
+				p.FinalCompletion("-" + string(arg))
+			} else {
+				p.status(observer.notifyShortFlag(name))
+			}
+			return
+		} else if mode == requiredValue {
 			c++
 			if c < len(arg) {
 				value := string(arg[c:])
@@ -147,6 +196,7 @@ func parseShortFlag(p *parser, arg []rune, observer parseObserver) {
 				handleShortFlagValue(p, name, value, observer)
 			} else {
 				if p.shouldComplete() {
+					p.prependCompletion = ""
 					p.FinalCompletion("-" + string(arg))
 				} else {
 					observer.Error("-" + string(name) + " requires an argument")
@@ -215,11 +265,20 @@ func parseMain(p *parser, observer parseObserver) {
 					p.status(false)
 				}
 			}
+		} else if observer.acceptingCommand() {
+			// Not a flag, selects a subcommand.
+			if p.shouldComplete() {
+				p.prependCompletion = ""
+				observer.completeCommand(string(arg), p)
+			} else {
+				p.status(observer.notifyCommand(string(arg)))
+			}
 		} else {
 			if p.shouldComplete() {
 				if len(arg) == 0 && !observer.acceptingArgs() {
 					completeAnyFlag(p, observer)
 				} else {
+					p.prependCompletion = ""
 					observer.completeArg(string(arg), p)
 				}
 			} else {
@@ -241,3 +300,9 @@ func complete(args []string, observer parseObserver) ([]string, bool) {
 	parseMain(p, observer)
 	return p.completions, p.isPartial
 }
+
+func describeCompletions(args []string, observer parseObserver) []candidate {
+	p := &parser{args: args, current: 0, parseOK: true, completing: true}
+	parseMain(p, observer)
+	return p.candidates
+}
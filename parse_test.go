@@ -8,9 +8,9 @@ import (
 )
 
 type mockFlag struct {
-	long   string
-	short  string
-	hasArg bool
+	long  string
+	short string
+	mode  valueMode
 }
 
 type mockParseObserver struct {
@@ -21,10 +21,12 @@ type mockParseObserver struct {
 	failAfter int
 	banArgs   bool
 	numErrors int
+	commands  []string
+	command   string
 }
 
-func (o *mockParseObserver) flag(long string, short string, hasArg bool) {
-	f := &mockFlag{long: long, short: short, hasArg: hasArg}
+func (o *mockParseObserver) flag(long string, short string, mode valueMode) {
+	f := &mockFlag{long: long, short: short, mode: mode}
 	o.all = append(o.all, f)
 	if long != "" {
 		o.long[long] = f
@@ -34,24 +36,28 @@ func (o *mockParseObserver) flag(long string, short string, hasArg bool) {
 	}
 }
 
-func (o *mockParseObserver) longFlagInfo(name string) (bool, bool) {
+func (o *mockParseObserver) longFlagInfo(name string) (bool, valueMode) {
 	f, ok := o.long[name]
 	if ok {
-		return true, f.hasArg
+		return true, f.mode
 	} else {
-		return false, false
+		return false, noValue
 	}
 }
 
-func (o *mockParseObserver) shortFlagInfo(name rune) (bool, bool) {
+func (o *mockParseObserver) shortFlagInfo(name rune) (bool, valueMode) {
 	f, ok := o.short[string(name)]
 	if ok {
-		return true, f.hasArg
+		return true, f.mode
 	} else {
-		return false, false
+		return false, noValue
 	}
 }
 
+func (o *mockParseObserver) unrecognizedLongFlag(name string) {
+	o.Error("unrecognized flag --" + name)
+}
+
 func (o *mockParseObserver) spaceIfNeeded() {
 	if o.b.Len() > 0 {
 		o.b.WriteString(" ")
@@ -123,6 +129,15 @@ func (o *mockParseObserver) notifyArg(value string) bool {
 	return o.injectFault() && !o.banArgs
 }
 
+func (o *mockParseObserver) notifyCommand(name string) bool {
+	o.spaceIfNeeded()
+	o.b.WriteString("(command ")
+	o.b.WriteString(name)
+	o.b.WriteString(")")
+	o.command = name
+	return o.injectFault()
+}
+
 func (o *mockParseObserver) completeLongFlag(prefix string, c CompletionObserver) {
 	for _, f := range o.all {
 		if f.long != "" && strings.HasPrefix(f.long, prefix) {
@@ -134,7 +149,7 @@ func (o *mockParseObserver) completeLongFlag(prefix string, c CompletionObserver
 func (o *mockParseObserver) completeShortFlag(c CompletionObserver) {
 	for _, f := range o.all {
 		if f.short != "" {
-			if f.hasArg {
+			if f.mode != noValue {
 				c.FinalCompletion(f.short)
 			} else {
 				c.PartialCompletion(f.short)
@@ -156,18 +171,30 @@ func (o *mockParseObserver) acceptingArgs() bool {
 	return !o.banArgs
 }
 
+func (o *mockParseObserver) completeCommand(prefix string, c CompletionObserver) {
+	for _, name := range o.commands {
+		if strings.HasPrefix(name, prefix) {
+			c.FinalCompletion(name)
+		}
+	}
+}
+
+func (o *mockParseObserver) acceptingCommand() bool {
+	return len(o.commands) > 0 && o.command == ""
+}
+
 func makeObserver(failAfter int) *mockParseObserver {
 	o := &mockParseObserver{
 		short:     map[string]*mockFlag{},
 		long:      map[string]*mockFlag{},
 		failAfter: failAfter,
 	}
-	o.flag("", "a", false)
-	o.flag("", "b", false)
-	o.flag("", "c", true)
-	o.flag("", "d", true)
-	o.flag("foo", "", false)
-	o.flag("bar", "", true)
+	o.flag("", "a", noValue)
+	o.flag("", "b", noValue)
+	o.flag("", "c", requiredValue)
+	o.flag("", "d", requiredValue)
+	o.flag("foo", "", noValue)
+	o.flag("bar", "", requiredValue)
 	return o
 }
 
@@ -353,3 +380,59 @@ func TestCompleteEmptyNoArgs(t *testing.T) {
 	options, _ := complete([]string{""}, o)
 	assert.Equal(t, []string{"-a", "-b", "-c", "-d", "--foo", "--bar"}, options)
 }
+
+func TestParseCommand(t *testing.T) {
+	o := makeObserver(-1)
+	o.commands = []string{"build", "test"}
+	assert.Equal(t, true, parse([]string{"-a", "build", "--foo", "abc"}, o))
+	assert.Equal(t, "(short a) (command build) (long foo) (arg abc)", o.b.String())
+}
+
+func TestParseCommandFault(t *testing.T) {
+	o := makeObserver(0)
+	o.commands = []string{"build", "test"}
+	assert.Equal(t, false, parse([]string{"build", "abc"}, o))
+	assert.Equal(t, "(command build)", o.b.String())
+}
+
+func TestCompleteCommand(t *testing.T) {
+	o := makeObserver(-1)
+	o.commands = []string{"build", "test"}
+	options, _ := complete([]string{""}, o)
+	assert.Equal(t, []string{"build", "test"}, options)
+}
+
+func TestCompleteCommandPartial(t *testing.T) {
+	o := makeObserver(-1)
+	o.commands = []string{"build", "test"}
+	options, _ := complete([]string{"-a", "t"}, o)
+	assert.Equal(t, []string{"test"}, options)
+}
+
+func TestCompleteAfterCommand(t *testing.T) {
+	o := makeObserver(-1)
+	o.commands = []string{"build", "test"}
+	options, _ := complete([]string{"build", "--"}, o)
+	assert.Equal(t, []string{"--", "--foo", "--bar"}, options)
+}
+
+func TestParseLongOptional(t *testing.T) {
+	o := makeObserver(-1)
+	o.flag("color", "o", optionalValue)
+	assert.Equal(t, true, parse([]string{"--color", "abc", "--color=always"}, o))
+	assert.Equal(t, "(long color) (arg abc) (long color=always)", o.b.String())
+}
+
+func TestParseShortOptional(t *testing.T) {
+	o := makeObserver(-1)
+	o.flag("color", "o", optionalValue)
+	assert.Equal(t, true, parse([]string{"-ao", "abc", "-aoalways"}, o))
+	assert.Equal(t, "(short a) (short o) (arg abc) (short a) (short o=always)", o.b.String())
+}
+
+func TestCompleteShortOptional(t *testing.T) {
+	o := makeObserver(-1)
+	o.flag("color", "o", optionalValue)
+	options, _ := complete([]string{"-ao"}, o)
+	assert.Equal(t, []string{"-ao"}, options)
+}
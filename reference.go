@@ -0,0 +1,316 @@
+package cmdline
+
+import (
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// flagReference is what reference documentation says about a flag.
+type flagReference struct {
+	names        string
+	typeName     string
+	defaultValue string
+	env          string
+	help         string
+	required     bool
+	choices      []string
+}
+
+type argumentReference struct {
+	name     string
+	typeName string
+	help     string
+}
+
+// commandReference gathers what reference documentation says about a command.
+// Only the flags a command declares are listed, inherited flags are listed
+// with the command that declares them.
+type commandReference struct {
+	command     *Command
+	depth       int
+	anchor      string
+	usage       string
+	flags       []flagReference
+	arguments   []argumentReference
+	constraints []string
+}
+
+func makeFlagReference(c *Command, f *Flag) flagReference {
+	r := flagReference{
+		names:    flagNames(f),
+		env:      c.envName(f),
+		help:     f.Help,
+		required: f.Min > 0,
+	}
+	if f.Value != nil {
+		r.choices = valueChoices(f.Value)
+		if f.Default != "" {
+			r.defaultValue = formatDefault(f.Value, f.Default)
+		}
+	}
+	switch {
+	case isCounter(f.Value):
+		r.typeName = f.Value.TypeName()
+	case f.ValueOptional:
+		r.typeName = "[=" + f.Value.TypeName() + "]"
+	case f.longMode() == requiredValue:
+		r.typeName = f.Value.TypeName()
+		if isRepeatable(f.Value) {
+			r.typeName += "..."
+		}
+	}
+	return r
+}
+
+func makeArgumentReference(name string, a *Argument) argumentReference {
+	r := argumentReference{name: name, help: a.Help}
+	if a.Value != nil {
+		r.typeName = a.Value.TypeName()
+	}
+	return r
+}
+
+// referenceAnchor is the fragment GitHub-style Markdown gives a heading.
+func referenceAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (app *App) commandReferences() []*commandReference {
+	refs := []*commandReference{}
+	var visit func(c *Command, depth int)
+	visit = func(c *Command, depth int) {
+		r := &commandReference{
+			command: c,
+			depth:   depth,
+			anchor:  referenceAnchor(c.Path()),
+			usage:   c.Path() + c.usageSuffix(),
+		}
+		for _, f := range c.allFlags {
+			r.flags = append(r.flags, makeFlagReference(c, f))
+		}
+		for _, a := range c.requiredArguments {
+			r.arguments = append(r.arguments, makeArgumentReference("<"+a.Name+">", a))
+		}
+		if c.excessArguments != nil {
+			r.arguments = append(r.arguments, makeArgumentReference("<"+c.excessArguments.Name+">...", c.excessArguments))
+		}
+		for _, constraint := range c.constraints {
+			r.constraints = append(r.constraints, constraint.describe())
+		}
+		refs = append(refs, r)
+		for _, sub := range c.subcommands {
+			visit(sub, depth+1)
+		}
+	}
+	visit(app.Command, 1)
+	return refs
+}
+
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + markdownCell(text) + "`"
+}
+
+// markdownCell keeps text from breaking out of a table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func markdownHeading(out io.Writer, depth int, text string) {
+	if depth > 6 {
+		depth = 6
+	}
+	io.WriteString(out, strings.Repeat("#", depth)+" "+text+"\n\n")
+}
+
+func flagDescription(r flagReference, code func(string) string, escape func(string) string) string {
+	parts := []string{}
+	if r.help != "" {
+		parts = append(parts, escape(r.help))
+	}
+	if len(r.choices) > 0 {
+		choices := []string{}
+		for _, choice := range r.choices {
+			choices = append(choices, code(choice))
+		}
+		parts = append(parts, "One of "+strings.Join(choices, ", ")+".")
+	}
+	if r.required {
+		parts = append(parts, "Required.")
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteMarkdown writes a Markdown reference for the app and all of its
+// subcommands.
+func (app *App) WriteMarkdown(out io.Writer) {
+	for _, r := range app.commandReferences() {
+		c := r.command
+		markdownHeading(out, r.depth, r.command.Path())
+		if c.Description != "" {
+			io.WriteString(out, strings.TrimSpace(c.Description)+"\n\n")
+		}
+		io.WriteString(out, "```\n"+r.usage+"\n```\n\n")
+
+		if len(r.flags) > 0 {
+			markdownHeading(out, r.depth+1, "Flags")
+			io.WriteString(out, "| Flag | Type | Default | Environment | Description |\n")
+			io.WriteString(out, "| --- | --- | --- | --- | --- |\n")
+			for _, f := range r.flags {
+				cells := []string{
+					markdownCode(f.names),
+					markdownCode(f.typeName),
+					markdownCode(f.defaultValue),
+					markdownCode(f.env),
+					flagDescription(f, markdownCode, markdownCell),
+				}
+				io.WriteString(out, "| "+strings.Join(cells, " | ")+" |\n")
+			}
+			io.WriteString(out, "\n")
+		}
+
+		if len(r.arguments) > 0 {
+			markdownHeading(out, r.depth+1, "Arguments")
+			io.WriteString(out, "| Argument | Type | Description |\n")
+			io.WriteString(out, "| --- | --- | --- |\n")
+			for _, a := range r.arguments {
+				io.WriteString(out, "| "+markdownCode(a.name)+" | "+markdownCode(a.typeName)+" | "+markdownCell(a.help)+" |\n")
+			}
+			io.WriteString(out, "\n")
+		}
+
+		if len(r.constraints) > 0 {
+			markdownHeading(out, r.depth+1, "Constraints")
+			for _, constraint := range r.constraints {
+				io.WriteString(out, "- "+constraint+"\n")
+			}
+			io.WriteString(out, "\n")
+		}
+
+		if len(c.subcommands) > 0 {
+			markdownHeading(out, r.depth+1, "Commands")
+			io.WriteString(out, "| Command | Description |\n")
+			io.WriteString(out, "| --- | --- |\n")
+			for _, sub := range c.subcommands {
+				link := "[" + markdownCode(sub.Name) + "](#" + referenceAnchor(sub.Path()) + ")"
+				io.WriteString(out, "| "+link+" | "+markdownCell(sub.summary())+" |\n")
+			}
+			io.WriteString(out, "\n")
+		}
+
+		if c.Epilogue != "" {
+			io.WriteString(out, strings.TrimSpace(c.Epilogue)+"\n\n")
+		}
+	}
+}
+
+func htmlCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "<code>" + html.EscapeString(text) + "</code>"
+}
+
+func htmlHeading(out io.Writer, depth int, id string, text string) {
+	if depth > 6 {
+		depth = 6
+	}
+	tag := "h" + strconv.Itoa(depth)
+	if id != "" {
+		io.WriteString(out, "<"+tag+" id=\""+id+"\">"+html.EscapeString(text)+"</"+tag+">\n")
+	} else {
+		io.WriteString(out, "<"+tag+">"+html.EscapeString(text)+"</"+tag+">\n")
+	}
+}
+
+func htmlParagraphs(out io.Writer, text string) {
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		io.WriteString(out, "<p>"+html.EscapeString(strings.TrimSpace(paragraph))+"</p>\n")
+	}
+}
+
+func htmlRow(out io.Writer, tag string, cells ...string) {
+	io.WriteString(out, "<tr>")
+	for _, cell := range cells {
+		io.WriteString(out, "<"+tag+">"+cell+"</"+tag+">")
+	}
+	io.WriteString(out, "</tr>\n")
+}
+
+// WriteHTML writes an HTML fragment with the same reference as WriteMarkdown,
+// for pages that embed it.
+func (app *App) WriteHTML(out io.Writer) {
+	for _, r := range app.commandReferences() {
+		c := r.command
+		io.WriteString(out, "<section>\n")
+		htmlHeading(out, r.depth, r.anchor, c.Path())
+		if c.Description != "" {
+			htmlParagraphs(out, c.Description)
+		}
+		io.WriteString(out, "<pre>"+html.EscapeString(r.usage)+"</pre>\n")
+
+		if len(r.flags) > 0 {
+			htmlHeading(out, r.depth+1, "", "Flags")
+			io.WriteString(out, "<table>\n")
+			htmlRow(out, "th", "Flag", "Type", "Default", "Environment", "Description")
+			for _, f := range r.flags {
+				htmlRow(out, "td",
+					htmlCode(f.names),
+					htmlCode(f.typeName),
+					htmlCode(f.defaultValue),
+					htmlCode(f.env),
+					flagDescription(f, htmlCode, html.EscapeString))
+			}
+			io.WriteString(out, "</table>\n")
+		}
+
+		if len(r.arguments) > 0 {
+			htmlHeading(out, r.depth+1, "", "Arguments")
+			io.WriteString(out, "<table>\n")
+			htmlRow(out, "th", "Argument", "Type", "Description")
+			for _, a := range r.arguments {
+				htmlRow(out, "td", htmlCode(a.name), htmlCode(a.typeName), html.EscapeString(a.help))
+			}
+			io.WriteString(out, "</table>\n")
+		}
+
+		if len(r.constraints) > 0 {
+			htmlHeading(out, r.depth+1, "", "Constraints")
+			io.WriteString(out, "<ul>\n")
+			for _, constraint := range r.constraints {
+				io.WriteString(out, "<li>"+html.EscapeString(constraint)+"</li>\n")
+			}
+			io.WriteString(out, "</ul>\n")
+		}
+
+		if len(c.subcommands) > 0 {
+			htmlHeading(out, r.depth+1, "", "Commands")
+			io.WriteString(out, "<table>\n")
+			htmlRow(out, "th", "Command", "Description")
+			for _, sub := range c.subcommands {
+				link := "<a href=\"#" + referenceAnchor(sub.Path()) + "\">" + htmlCode(sub.Name) + "</a>"
+				htmlRow(out, "td", link, html.EscapeString(sub.summary()))
+			}
+			io.WriteString(out, "</table>\n")
+		}
+
+		if c.Epilogue != "" {
+			htmlParagraphs(out, c.Epilogue)
+		}
+		io.WriteString(out, "</section>\n")
+	}
+}
@@ -0,0 +1,78 @@
+package cmdline
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func makeReferenceApp() *App {
+	var jobs int32
+	var format, message string
+	var verbosity int
+	app := MakeApp("tool")
+	app.Description = "Do things."
+	app.EnvPrefix("TOOL")
+	app.Flags([]*Flag{
+		{Long: "jobs", Short: 'j', Help: "Run this many jobs.", Value: Int32.Set(&jobs), Default: "4"},
+		{Long: "format", Help: "Output | format.", Value: (&Enum{Possible: []string{"json", "yaml"}}).Set(&format)},
+		{Long: "verbose", Short: 'v', Value: Counter(&verbosity)},
+	})
+	commit := app.Subcommand("commit")
+	commit.Description = "Record changes.\n\nMore detail."
+	commit.Flags([]*Flag{
+		{Long: "message", Short: 'm', Value: String.Set(&message), Min: 1},
+	})
+	commit.ExcessArguments(&Argument{Name: "file", Help: "Files to commit.", Value: String.Call(func(string) {})})
+	return app
+}
+
+func TestReferenceAnchor(t *testing.T) {
+	assert.Equal(t, "tool-remote-add", referenceAnchor("tool remote add"))
+	assert.Equal(t, "my_tool-v2", referenceAnchor("My_Tool v2!"))
+}
+
+func TestMarkdownReference(t *testing.T) {
+	app := makeReferenceApp()
+	var b bytes.Buffer
+	app.WriteMarkdown(&b)
+	assert.Equal(t, "# tool\n\n"+
+		"Do things.\n\n"+
+		"```\ntool [<flags>] <command>\n```\n\n"+
+		"## Flags\n\n"+
+		"| Flag | Type | Default | Environment | Description |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `-j/--jobs` | `int32` | `4` | `TOOL_JOBS` | Run this many jobs. |\n"+
+		"| `--format` | `{json,yaml}` |  | `TOOL_FORMAT` | Output \\| format. One of `json`, `yaml`. |\n"+
+		"| `-v/--verbose` | `count` |  | `TOOL_VERBOSE` |  |\n"+
+		"\n"+
+		"## Commands\n\n"+
+		"| Command | Description |\n"+
+		"| --- | --- |\n"+
+		"| [`commit`](#tool-commit) | Record changes. |\n"+
+		"\n"+
+		"## tool commit\n\n"+
+		"Record changes.\n\nMore detail.\n\n"+
+		"```\ntool commit [<flags>] [<file>...]\n```\n\n"+
+		"### Flags\n\n"+
+		"| Flag | Type | Default | Environment | Description |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `-m/--message` | `string` |  | `TOOL_MESSAGE` | Required. |\n"+
+		"\n"+
+		"### Arguments\n\n"+
+		"| Argument | Type | Description |\n"+
+		"| --- | --- | --- |\n"+
+		"| `<file>...` | `string` | Files to commit. |\n"+
+		"\n", b.String())
+}
+
+func TestHTMLReference(t *testing.T) {
+	app := makeReferenceApp()
+	result, err := app.Parse([]string{"--generate-html"})
+	assert.NoError(t, err)
+	output := result.Output
+	assert.Contains(t, output, "<section>\n<h1 id=\"tool\">tool</h1>\n<p>Do things.</p>\n<pre>tool [&lt;flags&gt;] &lt;command&gt;</pre>\n")
+	assert.Contains(t, output, "<tr><td><code>--format</code></td><td><code>{json,yaml}</code></td><td></td><td><code>TOOL_FORMAT</code></td><td>Output | format. One of <code>json</code>, <code>yaml</code>.</td></tr>\n")
+	assert.Contains(t, output, "<tr><td><a href=\"#tool-commit\"><code>commit</code></a></td><td>Record changes.</td></tr>\n")
+	assert.Contains(t, output, "<h2 id=\"tool-commit\">tool commit</h2>\n<p>Record changes.</p>\n<p>More detail.</p>\n")
+}
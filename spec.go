@@ -0,0 +1,283 @@
+package cmdline
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SpecVersion is the version of the format written by WriteSpec.  It changes
+// whenever a field is removed or its meaning changes, adding fields does not
+// change it.
+const SpecVersion = 1
+
+// Spec is a machine-readable description of an App's interface, for tools
+// that want to understand a command line without linking Go code.
+type Spec struct {
+	SpecVersion int `json:"spec_version"`
+	// Version is the app's own version, given to App.Version.
+	Version string `json:"version,omitempty"`
+	// EnvPrefix is the prefix given to App.EnvPrefix.
+	EnvPrefix string `json:"env_prefix,omitempty"`
+	// ConfigSearchPaths are the paths given to App.ConfigFile.
+	ConfigSearchPaths []string `json:"config_search_paths,omitempty"`
+	Abbreviations     bool     `json:"abbreviations,omitempty"`
+	*CommandSpec
+}
+
+type CommandSpec struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Epilogue    string            `json:"epilogue,omitempty"`
+	Runnable    bool              `json:"runnable,omitempty"`
+	Flags       []*FlagSpec       `json:"flags,omitempty"`
+	Arguments   []*ArgumentSpec   `json:"arguments,omitempty"`
+	Excess      *ArgumentSpec     `json:"excess,omitempty"`
+	Constraints []*ConstraintSpec `json:"constraints,omitempty"`
+	Commands    []*CommandSpec    `json:"commands,omitempty"`
+}
+
+// ValueSpec describes the value taken by a flag or argument.
+type ValueSpec struct {
+	// Kind identifies the value's type, it is one of "string", "enum", "path",
+	// "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8",
+	// "uint16", "uint32", "uint64", "float64", "duration", "time", "byte_size",
+	// "uint64_byte_size", "count", or "custom" for a ValueHandler defined
+	// outside this package.  For a value split on Separator it is the kind of
+	// each piece.
+	Kind string `json:"kind,omitempty"`
+	// TypeName is the value's TypeName, as shown in help.  It is meant for
+	// people and may change between releases.
+	TypeName  string   `json:"type_name,omitempty"`
+	Separator string   `json:"separator,omitempty"`
+	Choices   []string `json:"choices,omitempty"`
+	// Root and MustExist are set for paths.
+	Root      string `json:"root,omitempty"`
+	MustExist bool   `json:"must_exist,omitempty"`
+	// Layouts and Relative are set for times.
+	Layouts  []string `json:"layouts,omitempty"`
+	Relative bool     `json:"relative,omitempty"`
+}
+
+type FlagSpec struct {
+	Long  string `json:"long,omitempty"`
+	Short string `json:"short,omitempty"`
+	Help  string `json:"help,omitempty"`
+	// ValueSpec is empty if the flag doesn't take a value.
+	ValueSpec
+	Default       string `json:"default,omitempty"`
+	Env           string `json:"env,omitempty"`
+	Min           int    `json:"min,omitempty"`
+	Max           int    `json:"max"`
+	Repeatable    bool   `json:"repeatable,omitempty"`
+	Count         bool   `json:"count,omitempty"`
+	Negatable     bool   `json:"negatable,omitempty"`
+	ValueOptional bool   `json:"value_optional,omitempty"`
+	Implicit      string `json:"implicit,omitempty"`
+	// Builtin names the App feature that declared the flag: "help", "version"
+	// or "config".
+	Builtin string `json:"builtin,omitempty"`
+}
+
+type ArgumentSpec struct {
+	Name string `json:"name"`
+	Help string `json:"help,omitempty"`
+	ValueSpec
+}
+
+// ConstraintSpec relates flags, by long name.  Kind is "exclusive",
+// "at_least_one", "requires" or "conflicts".  Flag is only used by requires
+// and conflicts.
+type ConstraintSpec struct {
+	Kind  string   `json:"kind"`
+	Flag  string   `json:"flag,omitempty"`
+	Flags []string `json:"flags"`
+}
+
+var constraintKindNames = map[constraintKind]string{
+	exclusiveConstraint:  "exclusive",
+	atLeastOneConstraint: "at_least_one",
+	requiresConstraint:   "requires",
+	conflictsConstraint:  "conflicts",
+}
+
+// parserHandler is implemented by Handler[T], giving access to its Parser.
+type parserHandler interface {
+	parser() interface{}
+}
+
+// valueKind names the kind of value a parser produces, see ValueSpec.Kind.
+func valueKind(parser interface{}) string {
+	switch parser.(type) {
+	case *SimpleStringParser:
+		return "string"
+	case *Enum:
+		return "enum"
+	case *FilePath:
+		return "path"
+	case *BoolParser:
+		return "bool"
+	case *SignedParser[int]:
+		return "int"
+	case *SignedParser[int8]:
+		return "int8"
+	case *SignedParser[int16]:
+		return "int16"
+	case *SimpleInt32Parser, *SignedParser[int32]:
+		return "int32"
+	case *SignedParser[int64]:
+		return "int64"
+	case *UnsignedParser[uint]:
+		return "uint"
+	case *UnsignedParser[uint8]:
+		return "uint8"
+	case *UnsignedParser[uint16]:
+		return "uint16"
+	case *UnsignedParser[uint32]:
+		return "uint32"
+	case *UnsignedParser[uint64]:
+		return "uint64"
+	case *Float64Parser:
+		return "float64"
+	case *DurationParser:
+		return "duration"
+	case *TimeParser:
+		return "time"
+	case *ByteSizeParser[int64]:
+		return "byte_size"
+	case *ByteSizeParser[uint64]:
+		return "uint64_byte_size"
+	default:
+		return "custom"
+	}
+}
+
+// valueSpec describes a ValueHandler, looking through Separated.
+func valueSpec(handler ValueHandler) ValueSpec {
+	spec := ValueSpec{TypeName: handler.TypeName(), Choices: valueChoices(handler)}
+	wrapped, ok := handler.(*specHandler)
+	if ok {
+		handler = wrapped.ValueHandler
+	}
+	separated, ok := handler.(*separatedHandler)
+	if ok {
+		handler = separated.handler
+		spec.Separator = separated.separator
+	}
+	if isCounter(handler) {
+		spec.Kind = "count"
+		return spec
+	}
+	h, ok := handler.(parserHandler)
+	if !ok {
+		spec.Kind = "custom"
+		return spec
+	}
+	spec.Kind = valueKind(h.parser())
+	switch parser := h.parser().(type) {
+	case *FilePath:
+		spec.Root = parser.Root
+		spec.MustExist = parser.MustExist
+	case *TimeParser:
+		spec.Layouts = parser.Layouts
+		spec.Relative = parser.Relative
+	}
+	return spec
+}
+
+func (app *App) flagSpec(c *Command, f *Flag) *FlagSpec {
+	spec := &FlagSpec{
+		Long:          f.Long,
+		Help:          f.Help,
+		Default:       f.Default,
+		Env:           f.Env,
+		Min:           f.Min,
+		Max:           f.MaxUses(),
+		Negatable:     f.Negatable,
+		ValueOptional: f.ValueOptional,
+		Implicit:      f.Implicit,
+	}
+	if f.Short != 0 {
+		spec.Short = string(f.Short)
+	}
+	if f.Value != nil {
+		spec.ValueSpec = valueSpec(f.Value)
+		spec.Repeatable = isRepeatable(f.Value)
+		spec.Count = isCounter(f.Value)
+	}
+	switch f {
+	case app.helpFlag:
+		spec.Builtin = "help"
+	case app.versionFlag:
+		spec.Builtin = "version"
+	case app.configFlag:
+		spec.Builtin = "config"
+	}
+	return spec
+}
+
+func argumentSpec(a *Argument) *ArgumentSpec {
+	spec := &ArgumentSpec{Name: a.Name, Help: a.Help}
+	if a.Value != nil {
+		spec.ValueSpec = valueSpec(a.Value)
+	}
+	return spec
+}
+
+func constraintSpec(c *constraint) *ConstraintSpec {
+	spec := &ConstraintSpec{Kind: constraintKindNames[c.kind], Flags: []string{}}
+	if c.flag != nil {
+		spec.Flag = c.flag.Long
+	}
+	for _, f := range c.flags {
+		spec.Flags = append(spec.Flags, f.Long)
+	}
+	return spec
+}
+
+func (app *App) commandSpec(c *Command) *CommandSpec {
+	spec := &CommandSpec{
+		Name:        c.Name,
+		Description: c.Description,
+		Epilogue:    c.Epilogue,
+		Runnable:    c.Action != nil,
+	}
+	for _, f := range c.allFlags {
+		spec.Flags = append(spec.Flags, app.flagSpec(c, f))
+	}
+	for _, a := range c.requiredArguments {
+		spec.Arguments = append(spec.Arguments, argumentSpec(a))
+	}
+	if c.excessArguments != nil {
+		spec.Excess = argumentSpec(c.excessArguments)
+	}
+	for _, constraint := range c.constraints {
+		spec.Constraints = append(spec.Constraints, constraintSpec(constraint))
+	}
+	for _, sub := range c.subcommands {
+		spec.Commands = append(spec.Commands, app.commandSpec(sub))
+	}
+	return spec
+}
+
+// Spec describes the app's commands, flags and arguments.
+func (app *App) Spec() *Spec {
+	return &Spec{
+		SpecVersion:       SpecVersion,
+		Version:           app.version,
+		EnvPrefix:         app.envPrefix,
+		ConfigSearchPaths: app.configSearchPaths,
+		Abbreviations:     app.abbreviations,
+		CommandSpec:       app.commandSpec(app.Command),
+	}
+}
+
+// WriteSpec writes the app's Spec as indented JSON.  The same output is
+// available from the command line with --cli-spec.
+func (app *App) WriteSpec(out io.Writer) error {
+	data, err := json.MarshalIndent(app.Spec(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,321 @@
+package cmdline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// SpecValues collects the values parsed by an App built from a Spec.  Values
+// are keyed by the flag's long name (or short name, if it has no long name)
+// or the argument's name.  Repeatable flags and excess arguments hold a
+// []interface{}.  Durations and times are kept as text.  Values are not
+// cleared between calls to Parse.
+type SpecValues struct {
+	Values map[string]interface{}
+}
+
+func (v *SpecValues) record(key string, repeatable bool, value interface{}) {
+	switch typed := value.(type) {
+	case time.Duration:
+		value = typed.String()
+	case time.Time:
+		value = typed.Format(time.RFC3339Nano)
+	}
+	if !repeatable {
+		v.Values[key] = value
+		return
+	}
+	list, _ := v.Values[key].([]interface{})
+	v.Values[key] = append(list, value)
+}
+
+// specHandler gives a handler built for a spec the repeatability the spec
+// asks for.
+type specHandler struct {
+	ValueHandler
+	repeatable bool
+}
+
+func (h *specHandler) Repeatable() bool {
+	return h.repeatable
+}
+
+func (h *specHandler) Choices() []string {
+	return valueChoices(h.ValueHandler)
+}
+
+func (h *specHandler) FormatDefault(text string) string {
+	return formatDefault(h.ValueHandler, text)
+}
+
+// specCounter records the count every time it changes.
+type specCounter struct {
+	CountHandler[int]
+	record func(count int)
+}
+
+func (h *specCounter) Increment() {
+	h.CountHandler.Increment()
+	h.record(*h.Ptr)
+}
+
+func (h *specCounter) Notify(text string, log Logger) bool {
+	ok := h.CountHandler.Notify(text, log)
+	h.record(*h.Ptr)
+	return ok
+}
+
+func specCall[T any](factory HandlerFactory[T], record func(value interface{})) ValueHandler {
+	return factory.Call(func(value T) {
+		record(value)
+	})
+}
+
+// specValueHandler builds the ValueHandler a ValueSpec describes.
+func specValueHandler(spec ValueSpec, record func(value interface{})) (ValueHandler, error) {
+	var handler ValueHandler
+	kind := spec.Kind
+	if kind == "" && len(spec.Choices) > 0 {
+		kind = "enum"
+	}
+	switch kind {
+	case "enum":
+		handler = specCall[string](&Enum{Possible: spec.Choices}, record)
+	case "path":
+		handler = specCall[string](&FilePath{Root: spec.Root, MustExist: spec.MustExist}, record)
+	case "string":
+		handler = specCall(String, record)
+	case "bool":
+		handler = specCall(Bool, record)
+	case "int":
+		handler = specCall(Int, record)
+	case "int8":
+		handler = specCall(Int8, record)
+	case "int16":
+		handler = specCall(Int16, record)
+	case "int32":
+		handler = specCall(Int32, record)
+	case "int64":
+		handler = specCall(Int64, record)
+	case "uint":
+		handler = specCall(Uint, record)
+	case "uint8":
+		handler = specCall(Uint8, record)
+	case "uint16":
+		handler = specCall(Uint16, record)
+	case "uint32":
+		handler = specCall(Uint32, record)
+	case "uint64":
+		handler = specCall(Uint64, record)
+	case "float64":
+		handler = specCall(Float64, record)
+	case "duration":
+		handler = specCall(Duration, record)
+	case "time":
+		if len(spec.Layouts) > 0 {
+			handler = specCall(NewValue[time.Time](&TimeParser{Layouts: spec.Layouts, Relative: spec.Relative}), record)
+		} else {
+			handler = specCall(Time, record)
+		}
+	case "byte_size":
+		handler = specCall(ByteSize, record)
+	case "uint64_byte_size":
+		handler = specCall(UnsignedByteSize, record)
+	default:
+		return nil, fmt.Errorf("unsupported kind %#v", spec.Kind)
+	}
+	if spec.Separator != "" {
+		handler = Separated(spec.Separator, handler)
+	}
+	return handler, nil
+}
+
+func (app *App) loadFlagSpec(c *Command, spec *FlagSpec, values *SpecValues) error {
+	switch spec.Builtin {
+	case "help":
+		app.HelpFlag()
+		return nil
+	case "version":
+		app.Version(app.version)
+		return nil
+	case "config":
+		app.ConfigFile(app.configSearchPaths...)
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unknown builtin flag %#v", spec.Builtin)
+	}
+	f := &Flag{
+		Long:          spec.Long,
+		Help:          spec.Help,
+		Default:       spec.Default,
+		Env:           spec.Env,
+		Min:           spec.Min,
+		Max:           spec.Max,
+		Negatable:     spec.Negatable,
+		ValueOptional: spec.ValueOptional,
+		Implicit:      spec.Implicit,
+	}
+	if spec.Short != "" {
+		r, size := utf8.DecodeRuneInString(spec.Short)
+		if size != len(spec.Short) {
+			return fmt.Errorf("short name %#v is not a single character", spec.Short)
+		}
+		f.Short = r
+	}
+	key := spec.Long
+	if key == "" {
+		key = spec.Short
+	}
+	record := func(value interface{}) {
+		values.record(key, spec.Repeatable, value)
+	}
+	switch {
+	case spec.Count || spec.Kind == "count":
+		f.Value = &specCounter{
+			CountHandler: CountHandler[int]{Ptr: new(int)},
+			record: func(count int) {
+				values.Values[key] = count
+			},
+		}
+	case spec.Kind == "" && len(spec.Choices) == 0:
+		f.Call = func() {
+			record(true)
+		}
+	default:
+		handler, err := specValueHandler(spec.ValueSpec, record)
+		if err != nil {
+			return err
+		}
+		f.Value = &specHandler{ValueHandler: handler, repeatable: spec.Repeatable}
+	}
+	c.Flags([]*Flag{f})
+	return nil
+}
+
+func specArgument(spec *ArgumentSpec, repeatable bool, values *SpecValues) (*Argument, error) {
+	a := &Argument{Name: spec.Name, Help: spec.Help}
+	record := func(value interface{}) {
+		values.record(spec.Name, repeatable, value)
+	}
+	value := spec.ValueSpec
+	if value.Kind == "" {
+		value.Kind = "string"
+	}
+	handler, err := specValueHandler(value, record)
+	if err != nil {
+		return nil, err
+	}
+	a.Value = handler
+	return a, nil
+}
+
+func (app *App) loadCommandSpec(c *Command, spec *CommandSpec, values *SpecValues) error {
+	c.Description = spec.Description
+	c.Epilogue = spec.Epilogue
+	if spec.Runnable {
+		c.Action = func() {}
+	}
+	for _, flag := range spec.Flags {
+		err := app.loadFlagSpec(c, flag, values)
+		if err != nil {
+			return fmt.Errorf("%s: flag %s: %s", c.Path(), flag.Long+flag.Short, err)
+		}
+	}
+	args := []*Argument{}
+	for _, arg := range spec.Arguments {
+		a, err := specArgument(arg, false, values)
+		if err != nil {
+			return fmt.Errorf("%s: argument %s: %s", c.Path(), arg.Name, err)
+		}
+		args = append(args, a)
+	}
+	if len(args) > 0 {
+		c.RequiredArgs(args)
+	}
+	if spec.Excess != nil {
+		a, err := specArgument(spec.Excess, true, values)
+		if err != nil {
+			return fmt.Errorf("%s: argument %s: %s", c.Path(), spec.Excess.Name, err)
+		}
+		c.ExcessArguments(a)
+	}
+	for _, constraint := range spec.Constraints {
+		switch constraint.Kind {
+		case "exclusive":
+			c.Exclusive(constraint.Flags...)
+		case "at_least_one":
+			c.AtLeastOne(constraint.Flags...)
+		case "requires":
+			c.Requires(constraint.Flag, constraint.Flags...)
+		case "conflicts":
+			c.Conflicts(constraint.Flag, constraint.Flags...)
+		default:
+			return fmt.Errorf("%s: unknown constraint %#v", c.Path(), constraint.Kind)
+		}
+	}
+	for _, sub := range spec.Commands {
+		err := app.loadCommandSpec(c.Subcommand(sub.Name), sub, values)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakeAppFromSpec builds an App that accepts the command line a Spec
+// describes, so that programs not written in Go can use the parser and tab
+// completion.  Parsed values are collected in the returned SpecValues.
+func MakeAppFromSpec(spec *Spec) (app *App, values *SpecValues, err error) {
+	if spec.SpecVersion > SpecVersion {
+		return nil, nil, fmt.Errorf("spec version %d is newer than the supported version %d", spec.SpecVersion, SpecVersion)
+	}
+	if spec.CommandSpec == nil || spec.Name == "" {
+		return nil, nil, fmt.Errorf("spec has no name")
+	}
+	// Declarations panic on mistakes a Go program would make, for a spec they
+	// are errors in the input.
+	defer func() {
+		r := recover()
+		if r != nil {
+			app, values, err = nil, nil, fmt.Errorf("%v", r)
+		}
+	}()
+	app = MakeApp(spec.Name)
+	app.version = spec.Version
+	app.configSearchPaths = spec.ConfigSearchPaths
+	if spec.EnvPrefix != "" {
+		app.EnvPrefix(spec.EnvPrefix)
+	}
+	if spec.Abbreviations {
+		app.AllowAbbreviations()
+	}
+	values = &SpecValues{Values: map[string]interface{}{}}
+	err = app.loadCommandSpec(app.Command, spec.CommandSpec, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return app, values, nil
+}
+
+// LoadSpec reads a JSON spec, as written by WriteSpec, and builds an App from
+// it.
+func LoadSpec(path string) (*App, *SpecValues, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec := &Spec{}
+	err = json.Unmarshal(data, spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", path, err)
+	}
+	app, values, err := MakeAppFromSpec(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return app, values, nil
+}
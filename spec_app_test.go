@@ -0,0 +1,207 @@
+package cmdline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSpec = `{
+  "spec_version": 1,
+  "name": "deploy",
+  "env_prefix": "DEPLOY",
+  "flags": [
+    {"builtin": "help"},
+    {"long": "env", "short": "e", "choices": ["dev", "prod"], "min": 1},
+    {"long": "tag", "kind": "string", "separator": ",", "repeatable": true},
+    {"long": "timeout", "kind": "duration", "default": "30s"},
+    {"long": "verbose", "short": "v", "count": true},
+    {"long": "dry-run"}
+  ],
+  "arguments": [
+    {"name": "manifest", "kind": "path", "root": "ROOT", "must_exist": true}
+  ],
+  "excess": {"name": "host"},
+  "runnable": true
+}`
+
+func loadTestSpec(t *testing.T) (*App, *SpecValues, string) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "app.yaml"), []byte{}, 0644)
+	path := filepath.Join(dir, "spec.json")
+	os.WriteFile(path, []byte(strings.Replace(testSpec, "ROOT", dir, 1)), 0644)
+	app, values, err := LoadSpec(path)
+	assert.NoError(t, err)
+	return app, values, dir
+}
+
+func TestLoadSpec(t *testing.T) {
+	app, values, _ := loadTestSpec(t)
+	_, err := app.Parse([]string{"-e", "prod", "--tag", "a,b", "-vv", "--dry-run", "app.yaml", "web1", "web2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"env":      "prod",
+		"tag":      []interface{}{"a", "b"},
+		"timeout":  "30s",
+		"verbose":  2,
+		"dry-run":  true,
+		"manifest": "app.yaml",
+		"host":     []interface{}{"web1", "web2"},
+	}, values.Values)
+}
+
+func TestLoadSpecErrors(t *testing.T) {
+	app, _, _ := loadTestSpec(t)
+	_, err := app.Parse([]string{"-e", "qa", "app.yaml"})
+	assert.EqualError(t, err, "\"qa\" is not in {dev,prod}")
+	_, err = app.Parse([]string{"-e", "dev", "missing.yaml"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.yaml: no such file or directory")
+	_, err = app.Parse([]string{"app.yaml"})
+	assert.EqualError(t, err, "-e/--env is required")
+}
+
+func TestLoadSpecComplete(t *testing.T) {
+	app, _, _ := loadTestSpec(t)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "-e", "p"})
+	assert.NoError(t, err)
+	assert.Equal(t, "prod \n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "", "-e", "dev", "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "app.yaml \n", result.Output)
+}
+
+func TestSpecRoundTrip(t *testing.T) {
+	var format string
+	var jobs int32
+	original := MakeApp("tool")
+	original.HelpFlag()
+	original.Flags([]*Flag{
+		{Long: "format", Value: (&Enum{Possible: []string{"json", "yaml"}}).Set(&format), Default: "json"},
+		{Long: "jobs", Short: 'j', Help: "Run this many jobs.", Value: Int32.Set(&jobs)},
+	})
+	sub := original.Subcommand("run")
+	sub.Action = func() {}
+	sub.Flags([]*Flag{{Long: "quiet", Call: func() {}}, {Long: "loud", Call: func() {}}})
+	sub.Exclusive("quiet", "loud")
+
+	app, values, err := MakeAppFromSpec(original.Spec())
+	assert.NoError(t, err)
+	assert.Equal(t, original.Spec(), app.Spec())
+	_, err = app.Parse([]string{"run", "-j", "3", "--quiet"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"format": "json", "jobs": int32(3), "quiet": true}, values.Values)
+	_, err = app.Parse([]string{"run", "--quiet", "--loud"})
+	assert.EqualError(t, err, "--quiet, --loud cannot be used together")
+}
+
+func TestMakeAppFromSpecErrors(t *testing.T) {
+	_, _, err := MakeAppFromSpec(&Spec{SpecVersion: 2, CommandSpec: &CommandSpec{Name: "foo"}})
+	assert.EqualError(t, err, "spec version 2 is newer than the supported version 1")
+	_, _, err = MakeAppFromSpec(&Spec{CommandSpec: &CommandSpec{
+		Name:  "foo",
+		Flags: []*FlagSpec{{Long: "n", ValueSpec: ValueSpec{Kind: "complex"}}},
+	}})
+	assert.EqualError(t, err, "foo: flag n: unsupported kind \"complex\"")
+	_, _, err = MakeAppFromSpec(&Spec{CommandSpec: &CommandSpec{
+		Name:  "foo",
+		Flags: []*FlagSpec{{Long: "n"}, {Long: "n"}},
+	}})
+	assert.EqualError(t, err, "Tried to redefine --n")
+}
+
+func TestSpecRoundTripValueKinds(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "in.txt"), []byte{}, 0644)
+	var (
+		s       string
+		e       string
+		p       string
+		b       bool
+		i       int
+		i8      int8
+		i16     int16
+		i32     int32
+		i64     int64
+		u       uint
+		u8      uint8
+		u16     uint16
+		u32     uint32
+		u64     uint64
+		f64     float64
+		d       time.Duration
+		tm      time.Time
+		size    int64
+		usize   uint64
+		count   int
+		pieces  []string
+		special time.Time
+	)
+	original := MakeApp("tool")
+	original.Flags([]*Flag{
+		{Long: "string", Value: String.Set(&s)},
+		{Long: "enum", Value: (&Enum{Possible: []string{"a", "b"}}).Set(&e)},
+		{Long: "path", Value: (&FilePath{Root: dir, MustExist: true}).Set(&p)},
+		{Long: "bool", Value: Bool.Set(&b)},
+		{Long: "int", Value: Int.Set(&i)},
+		{Long: "int8", Value: Int8.Set(&i8)},
+		{Long: "int16", Value: Int16.Set(&i16)},
+		{Long: "int32", Value: Int32.Set(&i32)},
+		{Long: "int64", Value: Int64.Set(&i64)},
+		{Long: "uint", Value: Uint.Set(&u)},
+		{Long: "uint8", Value: Uint8.Set(&u8)},
+		{Long: "uint16", Value: Uint16.Set(&u16)},
+		{Long: "uint32", Value: Uint32.Set(&u32)},
+		{Long: "uint64", Value: Uint64.Set(&u64)},
+		{Long: "float64", Value: Float64.Set(&f64)},
+		{Long: "duration", Value: Duration.Set(&d)},
+		{Long: "time", Value: Time.Set(&tm)},
+		{Long: "special-time", Value: NewValue[time.Time](&TimeParser{Layouts: []string{"2006"}}).Set(&special)},
+		{Long: "size", Value: ByteSize.Set(&size)},
+		{Long: "usize", Value: UnsignedByteSize.Set(&usize)},
+		{Long: "count", Value: Counter(&count)},
+		{Long: "pieces", Value: Separated(",", String.Append(&pieces))},
+	})
+
+	app, values, err := MakeAppFromSpec(original.Spec())
+	assert.NoError(t, err)
+	assert.Equal(t, original.Spec(), app.Spec())
+	_, err = app.Parse([]string{
+		"--string", "x", "--enum", "b", "--path", "in.txt", "--bool", "true",
+		"--int", "-1", "--int8", "-8", "--int16", "-16", "--int32", "-2", "--int64", "-3",
+		"--uint", "1", "--uint8", "8", "--uint16", "2",
+		"--uint32", "3", "--uint64", "4", "--float64", "0.5", "--duration", "90s",
+		"--time", "2024-05-06", "--special-time", "1999", "--size", "1KiB", "--usize", "2KiB",
+		"--count", "--count", "--pieces", "p,q",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"string":       "x",
+		"enum":         "b",
+		"path":         "in.txt",
+		"bool":         true,
+		"int":          -1,
+		"int8":         int8(-8),
+		"int16":        int16(-16),
+		"int32":        int32(-2),
+		"int64":        int64(-3),
+		"uint":         uint(1),
+		"uint8":        uint8(8),
+		"uint16":       uint16(2),
+		"uint32":       uint32(3),
+		"uint64":       uint64(4),
+		"float64":      0.5,
+		"duration":     "1m30s",
+		"time":         "2024-05-06T00:00:00Z",
+		"special-time": "1999-01-01T00:00:00Z",
+		"size":         int64(1024),
+		"usize":        uint64(2048),
+		"count":        2,
+		"pieces":       []interface{}{"p", "q"},
+	}, values.Values)
+	_, err = app.Parse([]string{"--usize", "-1KiB"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package cmdline
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	var format, message string
+	var include []string
+	app := MakeApp("tool")
+	app.Description = "Do things."
+	app.HelpFlag()
+	app.Version("1.2")
+	app.EnvPrefix("TOOL")
+	app.Flags([]*Flag{
+		{Long: "format", Short: 'f', Value: (&Enum{Possible: []string{"json", "yaml"}}).Set(&format), Default: "json"},
+		{Long: "include", Value: Separated(",", String.Append(&include)), Min: 1},
+	})
+	commit := app.Subcommand("commit")
+	commit.Action = func() {}
+	commit.Flags([]*Flag{
+		{Long: "message", Short: 'm', Value: String.Set(&message)},
+		{Long: "amend", Call: func() {}},
+	})
+	commit.Conflicts("amend", "message")
+	commit.RequiredArgs([]*Argument{{Name: "repo", Value: String.Call(func(string) {})}})
+	commit.ExcessArguments(&Argument{Name: "file", Help: "Files to commit.", Value: String.Call(func(string) {})})
+
+	result, err := app.Parse([]string{"--cli-spec"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Done)
+	var spec Spec
+	assert.NoError(t, json.Unmarshal([]byte(result.Output), &spec))
+	assert.Equal(t, SpecVersion, spec.SpecVersion)
+	assert.Equal(t, "1.2", spec.Version)
+	assert.Equal(t, "TOOL", spec.EnvPrefix)
+	assert.Equal(t, "tool", spec.Name)
+	assert.Equal(t, false, spec.Runnable)
+	assert.Equal(t, []*FlagSpec{
+		{Long: "help", Short: "h", Help: "Show this help and exit.", Max: 1, Builtin: "help"},
+		{Long: "version", Help: "Show the version and exit.", Max: 1, Builtin: "version"},
+		{Long: "format", Short: "f", ValueSpec: ValueSpec{Kind: "enum", TypeName: "{json,yaml}", Choices: []string{"json", "yaml"}}, Default: "json", Max: 1},
+		{Long: "include", ValueSpec: ValueSpec{Kind: "string", TypeName: "string[,string...]", Separator: ","}, Min: 1, Max: Unlimited, Repeatable: true},
+	}, spec.Flags)
+	assert.Equal(t, 1, len(spec.Commands))
+	commitSpec := spec.Commands[0]
+	assert.Equal(t, "commit", commitSpec.Name)
+	assert.Equal(t, true, commitSpec.Runnable)
+	assert.Equal(t, []*ArgumentSpec{{Name: "repo", ValueSpec: ValueSpec{Kind: "string", TypeName: "string"}}}, commitSpec.Arguments)
+	assert.Equal(t, &ArgumentSpec{Name: "file", Help: "Files to commit.", ValueSpec: ValueSpec{Kind: "string", TypeName: "string"}}, commitSpec.Excess)
+	assert.Equal(t, []*ConstraintSpec{{Kind: "conflicts", Flag: "amend", Flags: []string{"message"}}}, commitSpec.Constraints)
+}
+
+func TestSpecEmpty(t *testing.T) {
+	app := MakeApp("foo")
+	result, err := app.Parse([]string{"--cli-spec"})
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"spec_version\": 1,\n  \"name\": \"foo\"\n}\n", result.Output)
+}
+
+func TestSpecValueKinds(t *testing.T) {
+	assert.Equal(t, ValueSpec{
+		Kind:     "time",
+		TypeName: "time (2006-01-02T15:04:05Z07:00 or 2006-01-02 or ±duration)",
+		Layouts:  []string{"2006-01-02T15:04:05Z07:00", "2006-01-02"},
+		Relative: true,
+	}, valueSpec(Time.Set(nil)))
+	assert.Equal(t, ValueSpec{
+		Kind:      "path",
+		TypeName:  "existing file in /srv",
+		Root:      "/srv",
+		MustExist: true,
+	}, valueSpec((&FilePath{Root: "/srv", MustExist: true}).Set(nil)))
+	assert.Equal(t, "uint64_byte_size", valueSpec(UnsignedByteSize.Set(nil)).Kind)
+	assert.Equal(t, "count", valueSpec(Counter(new(int))).Kind)
+	assert.Equal(t, "custom", valueSpec(NewValue[string](&upperParser{}).Set(nil)).Kind)
+}
@@ -0,0 +1,43 @@
+package cmdline
+
+// editDistance counts the insertions, deletions, substitutions and
+// transpositions of adjacent characters needed to turn a into b.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// suggest picks the candidate closest to text, if any is close enough to be
+// a plausible typo.  Returns "" otherwise.
+func suggest(text string, candidates []string) string {
+	best := ""
+	bestDistance := len([]rune(text))/3 + 1
+	for _, candidate := range candidates {
+		distance := editDistance(text, candidate)
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
@@ -0,0 +1,95 @@
+package cmdline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("abc", "abc"))
+	assert.Equal(t, 1, editDistance("verbositi", "verbosity"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 1, editDistance("amr", "arm"))
+}
+
+func TestSuggest(t *testing.T) {
+	assert.Equal(t, "verbosity", suggest("verbositi", []string{"verbose", "verbosity"}))
+	assert.Equal(t, "", suggest("x", []string{"jobs", "arch"}))
+}
+
+func TestSuggestFlag(t *testing.T) {
+	var verbosity int32
+	app := MakeApp("foo")
+	app.Flags([]*Flag{{Long: "verbosity", Value: Int32.Set(&verbosity)}})
+	_, err := app.Parse([]string{"--verbositi=1"})
+	assert.EqualError(t, err, "unrecognized flag --verbositi, did you mean --verbosity?")
+	_, err = app.Parse([]string{"--jobs"})
+	assert.EqualError(t, err, "unrecognized flag --jobs")
+}
+
+func TestSuggestCommand(t *testing.T) {
+	app := MakeApp("foo")
+	app.Subcommand("commit")
+	app.Subcommand("push")
+	_, err := app.Parse([]string{"comit"})
+	assert.EqualError(t, err, "unrecognized command comit, did you mean commit?")
+}
+
+func TestSuggestEnum(t *testing.T) {
+	p := &Enum{Possible: []string{"arm", "arm64", "x64"}}
+	_, err := p.Parse("amr64")
+	assert.EqualError(t, err, `"amr64" is not in {arm,arm64,x64}, did you mean "arm64"?`)
+	_, err = p.Parse("mips")
+	assert.EqualError(t, err, `"mips" is not in {arm,arm64,x64}`)
+}
+
+func makeAbbreviationApp(verbose *bool, verbosity *int32) *App {
+	app := MakeApp("foo")
+	app.AllowAbbreviations()
+	app.Flags([]*Flag{
+		{Long: "verbose", Call: SetTrue(verbose)},
+		{Long: "verbosity", Value: Int32.Set(verbosity)},
+		{Long: "arch", Value: (&Enum{Possible: []string{"arm", "x64"}}).Set(new(string))},
+	})
+	return app
+}
+
+func TestAbbreviation(t *testing.T) {
+	var verbose bool
+	var verbosity int32
+	app := makeAbbreviationApp(&verbose, &verbosity)
+	_, err := app.Parse([]string{"--verbosi", "3", "--verbose"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), verbosity)
+	assert.Equal(t, true, verbose)
+	_, err = app.Parse([]string{"--verbositi"})
+	assert.EqualError(t, err, "unrecognized flag --verbositi, did you mean --verbosity?")
+}
+
+func TestAbbreviationAmbiguous(t *testing.T) {
+	var verbose bool
+	var verbosity int32
+	app := makeAbbreviationApp(&verbose, &verbosity)
+	_, err := app.Parse([]string{"--verb"})
+	assert.EqualError(t, err, "ambiguous flag --verb, could be --verbose, --verbosity")
+}
+
+func TestAbbreviationDisabled(t *testing.T) {
+	app := MakeApp("foo")
+	app.Flags([]*Flag{{Long: "verbose", Call: func() {}}})
+	_, err := app.Parse([]string{"--verb"})
+	assert.EqualError(t, err, "unrecognized flag --verb")
+}
+
+func TestAbbreviationComplete(t *testing.T) {
+	var verbose bool
+	var verbosity int32
+	app := makeAbbreviationApp(&verbose, &verbosity)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--ar=x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--ar=x64 \n", result.Output)
+	result, err = app.Parse([]string{"--generate-bash-completion", "", "--ar", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "arm\nx64\n", result.Output)
+}
@@ -0,0 +1,112 @@
+package cmdline
+
+// Parser converts text into a T.  Implementing a Parser is all that is needed
+// to add a new type of value, NewValue turns it into a HandlerFactory.
+type Parser[T any] interface {
+	Parse(text string) (T, error)
+	Complete(text string, observer CompletionObserver)
+	TypeName() string
+}
+
+// Formatter may be implemented by a Parser[T] to control how values are
+// presented to the user.
+type Formatter[T any] interface {
+	Format(value T) string
+}
+
+// HandlerFactory creates ValueHandlers that deliver parsed values of type T.
+type HandlerFactory[T any] interface {
+	Set(ptr *T) ValueHandler
+	Call(func(value T)) ValueHandler
+	Append(ptr *[]T) ValueHandler
+}
+
+// Value is the HandlerFactory for a Parser.
+type Value[T any] struct {
+	Parser Parser[T]
+}
+
+func NewValue[T any](parser Parser[T]) *Value[T] {
+	return &Value[T]{Parser: parser}
+}
+
+func (v *Value[T]) Set(ptr *T) ValueHandler {
+	return &Handler[T]{Parser: v.Parser, Ptr: ptr}
+}
+
+func (v *Value[T]) Call(callback func(value T)) ValueHandler {
+	return &Handler[T]{Parser: v.Parser, Callback: callback}
+}
+
+func (v *Value[T]) Append(ptr *[]T) ValueHandler {
+	return &Handler[T]{Parser: v.Parser, Slice: ptr}
+}
+
+// Handler is a ValueHandler that parses text with Parser and delivers the
+// result to exactly one of Callback, Ptr or Slice.
+type Handler[T any] struct {
+	Parser         Parser[T]
+	Callback       func(value T)
+	Ptr            *T
+	Slice          *[]T
+	AffectsParsing bool
+}
+
+func (h *Handler[T]) Notify(text string, log Logger) bool {
+	value, err := h.Parser.Parse(text)
+	if err != nil {
+		log.Error(err.Error())
+		return !h.AffectsParsing
+	} else if h.Callback != nil {
+		h.Callback(value)
+		return true
+	} else if h.Ptr != nil {
+		*h.Ptr = value
+		return true
+	} else if h.Slice != nil {
+		*h.Slice = append(*h.Slice, value)
+		return true
+	} else {
+		log.Error("missing consumer")
+		return !h.AffectsParsing
+	}
+}
+
+func (h *Handler[T]) Complete(text string, observer CompletionObserver) {
+	h.Parser.Complete(text, observer)
+}
+
+func (h *Handler[T]) TypeName() string {
+	return h.Parser.TypeName()
+}
+
+func (h *Handler[T]) Repeatable() bool {
+	return h.Slice != nil
+}
+
+func (h *Handler[T]) parser() interface{} {
+	return h.Parser
+}
+
+// Choices lists the values the Parser accepts, if it has a Choices method.
+func (h *Handler[T]) Choices() []string {
+	c, ok := h.Parser.(interface{ Choices() []string })
+	if ok {
+		return c.Choices()
+	}
+	return nil
+}
+
+// FormatDefault uses the Parser's Format method, if it has one, to present a
+// default value.
+func (h *Handler[T]) FormatDefault(text string) string {
+	formatter, ok := h.Parser.(Formatter[T])
+	if !ok {
+		return text
+	}
+	value, err := h.Parser.Parse(text)
+	if err != nil {
+		return text
+	}
+	return formatter.Format(value)
+}
@@ -0,0 +1,33 @@
+package cmdline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoolParser accepts the same spellings as strconv.ParseBool.
+type BoolParser struct {
+}
+
+func (p *BoolParser) Parse(text string) (bool, error) {
+	value, err := strconv.ParseBool(text)
+	if err != nil {
+		return false, &parseError{message: fmt.Sprintf("%#v cannot be converted into a bool", text)}
+	}
+	return value, nil
+}
+
+func (p *BoolParser) Complete(text string, observer CompletionObserver) {
+	for _, possible := range []string{"true", "false"} {
+		if strings.HasPrefix(possible, text) {
+			observer.FinalCompletion(possible)
+		}
+	}
+}
+
+func (p *BoolParser) TypeName() string {
+	return "bool"
+}
+
+var Bool HandlerFactory[bool] = NewValue[bool](&BoolParser{})
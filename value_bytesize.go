@@ -0,0 +1,128 @@
+package cmdline
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+type byteUnit struct {
+	suffix string
+	size   uint64
+}
+
+// byteUnits are the canonical spellings, smallest first.  Parsing is case
+// insensitive and also accepts the suffixes without the trailing "B".
+var byteUnits = []byteUnit{
+	{"B", 1},
+	{"kB", 1000},
+	{"KiB", 1 << 10},
+	{"MB", 1000 * 1000},
+	{"MiB", 1 << 20},
+	{"GB", 1000 * 1000 * 1000},
+	{"GiB", 1 << 30},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"TiB", 1 << 40},
+	{"PB", 1000 * 1000 * 1000 * 1000 * 1000},
+	{"PiB", 1 << 50},
+	{"EB", 1000 * 1000 * 1000 * 1000 * 1000 * 1000},
+	{"EiB", 1 << 60},
+}
+
+func byteUnitSize(suffix string) (uint64, bool) {
+	if suffix == "" {
+		return 1, true
+	}
+	suffix = strings.ToLower(suffix)
+	for _, unit := range byteUnits {
+		canonical := strings.ToLower(unit.suffix)
+		if suffix == canonical || suffix+"b" == canonical {
+			return unit.size, true
+		}
+	}
+	return 0, false
+}
+
+// splitByteSize separates the number from the unit.
+func splitByteSize(text string) (string, string) {
+	split := strings.LastIndexAny(text, "0123456789.") + 1
+	return text[:split], text[split:]
+}
+
+// ByteSizeParser parses sizes such as "512", "10MiB" or "1.5G".  Both SI
+// (kB, MB, ...) and IEC (KiB, MiB, ...) units are understood.
+type ByteSizeParser[T int64 | uint64] struct {
+}
+
+func (p *ByteSizeParser[T]) Parse(text string) (T, error) {
+	number, suffix := splitByteSize(text)
+	unit, ok := byteUnitSize(suffix)
+	if !ok || number == "" {
+		return 0, numberError(text, p.TypeName(), strconv.ErrSyntax)
+	}
+	limit := uint64(math.MaxUint64)
+	if ^T(0) < 0 {
+		// Signed.
+		limit = math.MaxInt64
+	}
+	if !strings.Contains(number, ".") {
+		value, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return 0, numberError(text, p.TypeName(), err)
+		}
+		if value > limit/unit {
+			return 0, numberError(text, p.TypeName(), strconv.ErrRange)
+		}
+		return T(value * unit), nil
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, numberError(text, p.TypeName(), err)
+	}
+	if value < 0 {
+		return 0, numberError(text, p.TypeName(), strconv.ErrSyntax)
+	}
+	value *= float64(unit)
+	// float64(limit) rounds up to a power of two, which is itself too large.
+	if value >= float64(limit) {
+		return 0, numberError(text, p.TypeName(), strconv.ErrRange)
+	}
+	return T(value), nil
+}
+
+// Complete suggests units once a number has been typed.
+func (p *ByteSizeParser[T]) Complete(text string, observer CompletionObserver) {
+	number, suffix := splitByteSize(text)
+	if number == "" {
+		return
+	}
+	for _, unit := range byteUnits {
+		if len(unit.suffix) > len(suffix) && strings.HasPrefix(strings.ToLower(unit.suffix), strings.ToLower(suffix)) {
+			// Units come out in their canonical spelling, the shell replaces the
+			// whole word.
+			observer.FinalCompletion(number + unit.suffix)
+		}
+	}
+}
+
+func (p *ByteSizeParser[T]) TypeName() string {
+	return "byte size"
+}
+
+// Format writes the size using the largest unit that represents it exactly.
+func (p *ByteSizeParser[T]) Format(value T) string {
+	size := uint64(value)
+	if size == 0 {
+		return "0"
+	}
+	for i := len(byteUnits) - 1; i >= 0; i-- {
+		unit := byteUnits[i]
+		if size%unit.size == 0 {
+			return strconv.FormatUint(size/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatUint(size, 10)
+}
+
+var ByteSize HandlerFactory[int64] = NewValue[int64](&ByteSizeParser[int64]{})
+var UnsignedByteSize HandlerFactory[uint64] = NewValue[uint64](&ByteSizeParser[uint64]{})
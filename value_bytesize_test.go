@@ -0,0 +1,69 @@
+package cmdline
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	p := &ByteSizeParser[int64]{}
+	for text, expected := range map[string]int64{
+		"512":   512,
+		"10MiB": 10 << 20,
+		"10mib": 10 << 20,
+		"1.5G":  1500000000,
+		"2k":    2000,
+		"2KB":   2000,
+		"2Ki":   2048,
+		"7EiB":  7 << 60,
+	} {
+		value, err := p.Parse(text)
+		assert.NoError(t, err, text)
+		assert.Equal(t, expected, value, text)
+	}
+	_, err := p.Parse("10XB")
+	assert.EqualError(t, err, `"10XB" cannot be converted into a byte size`)
+	_, err = p.Parse("MiB")
+	assert.EqualError(t, err, `"MiB" cannot be converted into a byte size`)
+	_, err = p.Parse("8EiB")
+	assert.EqualError(t, err, `"8EiB" is out of range for a byte size`)
+	_, err = p.Parse("8.5EiB")
+	assert.EqualError(t, err, `"8.5EiB" is out of range for a byte size`)
+}
+
+func TestParseUnsignedByteSize(t *testing.T) {
+	p := &ByteSizeParser[uint64]{}
+	value, err := p.Parse("8EiB")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8)<<60, value)
+	_, err = p.Parse("16EiB")
+	assert.EqualError(t, err, `"16EiB" is out of range for a byte size`)
+}
+
+func TestCompleteByteSize(t *testing.T) {
+	p := &ByteSizeParser[int64]{}
+	c := &parser{}
+	p.Complete("10m", c)
+	assert.Equal(t, []string{"10MB", "10MiB"}, c.completions)
+	c = &parser{}
+	p.Complete("10k", c)
+	assert.Equal(t, []string{"10kB", "10KiB"}, c.completions)
+	c = &parser{}
+	p.Complete("1.5", c)
+	assert.Equal(t, 13, len(c.completions))
+	c = &parser{}
+	p.Complete("", c)
+	assert.Equal(t, []string(nil), c.completions)
+}
+
+func TestByteSizeHelpDefault(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	var size int64
+	app := MakeApp("foo")
+	app.Flags([]*Flag{{Long: "max-bytes", Value: ByteSize.Set(&size), Default: "10485760"}})
+	var b bytes.Buffer
+	app.WriteHelp(&b)
+	assert.Equal(t, "usage: foo [<flags>]\n\nFlags:\n    --max-bytes byte size   [default=10MiB]\n", b.String())
+	assert.Equal(t, "1500kB", (&ByteSizeParser[int64]{}).Format(1500000))
+}
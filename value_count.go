@@ -0,0 +1,61 @@
+package cmdline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// counter is implemented by ValueHandlers for flags that take no argument and
+// instead count how many times they are given.
+type counter interface {
+	Increment()
+}
+
+func isCounter(handler ValueHandler) bool {
+	_, ok := handler.(counter)
+	return ok
+}
+
+// Counter makes a flag that adds one to *ptr each time it is given, so "-vvv"
+// or "-v -v -v" sets it to 3.  A count from a default, the environment or a
+// config file is written as a number.
+func Counter[T signed | unsigned](ptr *T) ValueHandler {
+	return &CountHandler[T]{Ptr: ptr}
+}
+
+type CountHandler[T signed | unsigned] struct {
+	Ptr *T
+}
+
+func (h *CountHandler[T]) Increment() {
+	*h.Ptr++
+}
+
+func (h *CountHandler[T]) Notify(text string, log Logger) bool {
+	value, err := strconv.ParseUint(text, 10, 64)
+	// Converting back detects counts that don't fit in T.
+	if err == nil && uint64(T(value)) != value {
+		err = strconv.ErrRange
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		log.Error(numberError(text, h.TypeName(), err).Error())
+		return true
+	} else if err != nil {
+		log.Error(fmt.Sprintf("%#v is not a count", text))
+		return true
+	}
+	*h.Ptr = T(value)
+	return true
+}
+
+func (h *CountHandler[T]) Complete(text string, observer CompletionObserver) {
+}
+
+func (h *CountHandler[T]) TypeName() string {
+	return "count"
+}
+
+func (h *CountHandler[T]) Repeatable() bool {
+	return true
+}
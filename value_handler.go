@@ -1,5 +1,9 @@
 package cmdline
 
+import (
+	"strings"
+)
+
 type parseError struct {
 	message string
 }
@@ -13,3 +17,105 @@ type ValueHandler interface {
 	Complete(text string, observer CompletionObserver)
 	TypeName() string
 }
+
+// RepeatableValue is implemented by ValueHandlers that accumulate every value
+// they are given rather than keeping the last one.
+type RepeatableValue interface {
+	Repeatable() bool
+}
+
+func isRepeatable(handler ValueHandler) bool {
+	r, ok := handler.(RepeatableValue)
+	return ok && r.Repeatable()
+}
+
+// DefaultFormatter is implemented by ValueHandlers that can present a default
+// value more readably in help, such as "10MiB" instead of "10485760".
+type DefaultFormatter interface {
+	FormatDefault(text string) string
+}
+
+func formatDefault(handler ValueHandler, text string) string {
+	f, ok := handler.(DefaultFormatter)
+	if ok {
+		return f.FormatDefault(text)
+	}
+	return text
+}
+
+// ChoicesValue is implemented by ValueHandlers that accept only a fixed set of
+// values, so that documentation can list them.
+type ChoicesValue interface {
+	Choices() []string
+}
+
+func valueChoices(handler ValueHandler) []string {
+	c, ok := handler.(ChoicesValue)
+	if ok {
+		return c.Choices()
+	}
+	return nil
+}
+
+// Separated splits each value on separator and passes the pieces to handler
+// one at a time, so "--include a,b" acts like "--include a --include b".
+func Separated(separator string, handler ValueHandler) ValueHandler {
+	return &separatedHandler{separator: separator, handler: handler}
+}
+
+type separatedHandler struct {
+	separator string
+	handler   ValueHandler
+}
+
+func (h *separatedHandler) Notify(text string, log Logger) bool {
+	ok := true
+	for _, piece := range strings.Split(text, h.separator) {
+		if !h.handler.Notify(piece, log) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (h *separatedHandler) Complete(text string, observer CompletionObserver) {
+	i := strings.LastIndex(text, h.separator)
+	if i < 0 {
+		h.handler.Complete(text, observer)
+		return
+	}
+	split := i + len(h.separator)
+	h.handler.Complete(text[split:], &prefixObserver{prefix: text[:split], observer: observer})
+}
+
+func (h *separatedHandler) TypeName() string {
+	name := h.handler.TypeName()
+	return name + "[" + h.separator + name + "...]"
+}
+
+func (h *separatedHandler) Repeatable() bool {
+	return isRepeatable(h.handler)
+}
+
+func (h *separatedHandler) Choices() []string {
+	return valueChoices(h.handler)
+}
+
+// prefixObserver prepends text that has already been consumed to every
+// completion.
+type prefixObserver struct {
+	prefix   string
+	observer CompletionObserver
+}
+
+func (o *prefixObserver) PartialCompletion(completion string) {
+	o.observer.PartialCompletion(o.prefix + completion)
+}
+
+func (o *prefixObserver) FinalCompletion(completion string) {
+	o.observer.FinalCompletion(o.prefix + completion)
+}
+
+func (o *prefixObserver) DescribedCompletion(completion string, description string, partial bool) {
+	o.observer.DescribedCompletion(o.prefix+completion, description, partial)
+}
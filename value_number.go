@@ -0,0 +1,101 @@
+package cmdline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// numberError describes why text could not be parsed as the named type.
+func numberError(text string, typeName string, err error) error {
+	// "u" is left out because "uint" is read as "you-int".
+	article := "a"
+	if strings.IndexByte("aeio", typeName[0]) >= 0 {
+		article = "an"
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return &parseError{message: fmt.Sprintf("%#v is out of range for %s %s", text, article, typeName)}
+	}
+	return &parseError{message: fmt.Sprintf("%#v cannot be converted into %s %s", text, article, typeName)}
+}
+
+type signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// SignedParser parses signed integers that fit in Bits bits.  Like Go
+// literals, a 0x, 0o or 0b prefix selects the base.
+type SignedParser[T signed] struct {
+	Bits int
+	Name string
+}
+
+func (p *SignedParser[T]) Parse(text string) (T, error) {
+	value, err := strconv.ParseInt(text, 0, p.Bits)
+	if err != nil {
+		return 0, numberError(text, p.Name, err)
+	}
+	return T(value), nil
+}
+
+func (p *SignedParser[T]) Complete(text string, observer CompletionObserver) {
+}
+
+func (p *SignedParser[T]) TypeName() string {
+	return p.Name
+}
+
+// UnsignedParser parses unsigned integers that fit in Bits bits.
+type UnsignedParser[T unsigned] struct {
+	Bits int
+	Name string
+}
+
+func (p *UnsignedParser[T]) Parse(text string) (T, error) {
+	value, err := strconv.ParseUint(text, 0, p.Bits)
+	if err != nil {
+		return 0, numberError(text, p.Name, err)
+	}
+	return T(value), nil
+}
+
+func (p *UnsignedParser[T]) Complete(text string, observer CompletionObserver) {
+}
+
+func (p *UnsignedParser[T]) TypeName() string {
+	return p.Name
+}
+
+type Float64Parser struct {
+}
+
+func (p *Float64Parser) Parse(text string) (float64, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, numberError(text, "float64", err)
+	}
+	return value, nil
+}
+
+func (p *Float64Parser) Complete(text string, observer CompletionObserver) {
+}
+
+func (p *Float64Parser) TypeName() string {
+	return "float64"
+}
+
+var Int HandlerFactory[int] = NewValue[int](&SignedParser[int]{Bits: strconv.IntSize, Name: "int"})
+var Int8 HandlerFactory[int8] = NewValue[int8](&SignedParser[int8]{Bits: 8, Name: "int8"})
+var Int16 HandlerFactory[int16] = NewValue[int16](&SignedParser[int16]{Bits: 16, Name: "int16"})
+var Int64 HandlerFactory[int64] = NewValue[int64](&SignedParser[int64]{Bits: 64, Name: "int64"})
+var Uint HandlerFactory[uint] = NewValue[uint](&UnsignedParser[uint]{Bits: strconv.IntSize, Name: "uint"})
+var Uint8 HandlerFactory[uint8] = NewValue[uint8](&UnsignedParser[uint8]{Bits: 8, Name: "uint8"})
+var Uint16 HandlerFactory[uint16] = NewValue[uint16](&UnsignedParser[uint16]{Bits: 16, Name: "uint16"})
+var Uint32 HandlerFactory[uint32] = NewValue[uint32](&UnsignedParser[uint32]{Bits: 32, Name: "uint32"})
+var Uint64 HandlerFactory[uint64] = NewValue[uint64](&UnsignedParser[uint64]{Bits: 64, Name: "uint64"})
+var Float64 HandlerFactory[float64] = NewValue[float64](&Float64Parser{})
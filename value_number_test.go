@@ -0,0 +1,118 @@
+package cmdline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseInt32(t *testing.T) {
+	p := &SimpleInt32Parser{}
+	value, err := p.Parse("0x10")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(16), value)
+	_, err = p.Parse("abc")
+	assert.EqualError(t, err, `"abc" cannot be converted into an int32`)
+	_, err = p.Parse("3000000000")
+	assert.EqualError(t, err, `"3000000000" is out of range for an int32`)
+}
+
+func TestParseInt64(t *testing.T) {
+	p := &SignedParser[int64]{Bits: 64, Name: "int64"}
+	value, err := p.Parse("-5000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-5000000000), value)
+	_, err = p.Parse("1.5")
+	assert.EqualError(t, err, `"1.5" cannot be converted into an int64`)
+}
+
+func TestParseUint16(t *testing.T) {
+	p := &UnsignedParser[uint16]{Bits: 16, Name: "uint16"}
+	value, err := p.Parse("8080")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(8080), value)
+	_, err = p.Parse("65536")
+	assert.EqualError(t, err, `"65536" is out of range for a uint16`)
+	_, err = p.Parse("-1")
+	assert.EqualError(t, err, `"-1" cannot be converted into a uint16`)
+}
+
+func TestParseFloat64(t *testing.T) {
+	p := &Float64Parser{}
+	value, err := p.Parse("0.25")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, value)
+	_, err = p.Parse("1e999")
+	assert.EqualError(t, err, `"1e999" is out of range for a float64`)
+}
+
+func TestParseBool(t *testing.T) {
+	var enable bool
+	app := MakeApp("foo")
+	app.Flags([]*Flag{{Long: "enable-x", Value: Bool.Set(&enable), Default: "true"}})
+	_, err := app.Parse([]string{"--enable-x=false"})
+	assert.NoError(t, err)
+	assert.Equal(t, false, enable)
+	_, err = app.Parse([]string{"--enable-x", "maybe"})
+	assert.EqualError(t, err, `"maybe" cannot be converted into a bool`)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "--enable-x=f"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--enable-x=false \n", result.Output)
+}
+
+func makeCounterApp(verbosity *int32) *App {
+	app := MakeApp("foo")
+	app.EnvPrefix("FOO")
+	app.Flags([]*Flag{
+		{Long: "verbose", Short: 'v', Value: Counter(verbosity), Max: 1},
+		{Short: 'q', Call: func() {}},
+	})
+	return app
+}
+
+func TestCounter(t *testing.T) {
+	var verbosity int32
+	app := makeCounterApp(&verbosity)
+	_, err := app.Parse([]string{"-vvqv", "--verbose", "-v"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), verbosity)
+}
+
+func TestCounterEnv(t *testing.T) {
+	var verbosity int32
+	app := makeCounterApp(&verbosity)
+	t.Setenv("FOO_VERBOSE", "2")
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), verbosity)
+	t.Setenv("FOO_VERBOSE", "lots")
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, `$FOO_VERBOSE: "lots" is not a count`)
+}
+
+func TestCounterRange(t *testing.T) {
+	var tiny int8
+	var small uint8
+	app := MakeApp("foo")
+	app.Flags([]*Flag{
+		{Long: "tiny", Value: Counter(&tiny)},
+		{Long: "small", Value: Counter(&small)},
+	})
+	_, err := app.Parse([]string{"--tiny=127", "--small=255"})
+	assert.NoError(t, err)
+	assert.Equal(t, int8(127), tiny)
+	assert.Equal(t, uint8(255), small)
+	_, err = app.Parse([]string{"--tiny=300"})
+	assert.EqualError(t, err, `"300" is out of range for a count`)
+	_, err = app.Parse([]string{"--tiny=128"})
+	assert.EqualError(t, err, `"128" is out of range for a count`)
+	_, err = app.Parse([]string{"--small=99999999999999999999"})
+	assert.EqualError(t, err, `"99999999999999999999" is out of range for a count`)
+}
+
+func TestCounterComplete(t *testing.T) {
+	var verbosity int32
+	app := makeCounterApp(&verbosity)
+	result, err := app.Parse([]string{"--generate-bash-completion", "", "-vv"})
+	assert.NoError(t, err)
+	assert.Equal(t, "-vv\n-vvv\n-vvq\n", result.Output)
+}
@@ -8,19 +8,11 @@ import (
 	"strings"
 )
 
-type StringHandlerFactory interface {
-	Set(ptr *string) ValueHandler
-	Call(func(value string)) ValueHandler
-}
-
-type StringParser interface {
-	Parse(text string) (string, error)
-	Complete(text string, observer CompletionObserver)
-	TypeName() string
-}
+type StringHandlerFactory = HandlerFactory[string]
+type StringParser = Parser[string]
+type StringHandler = Handler[string]
 
 type SimpleStringParser struct {
-	StringHandlerFactory
 }
 
 func (p *SimpleStringParser) Parse(text string) (string, error) {
@@ -34,47 +26,7 @@ func (p *SimpleStringParser) TypeName() string {
 	return "string"
 }
 
-func (p *SimpleStringParser) Set(ptr *string) ValueHandler {
-	return &StringHandler{Parser: p, Ptr: ptr}
-}
-
-func (p *SimpleStringParser) Call(callback func(value string)) ValueHandler {
-	return &StringHandler{Parser: p, Callback: callback}
-}
-
-var String StringHandlerFactory = &SimpleStringParser{}
-
-type StringHandler struct {
-	Parser         StringParser
-	Callback       func(value string)
-	Ptr            *string
-	AffectsParsing bool
-}
-
-func (h *StringHandler) Notify(text string, log Logger) bool {
-	value, err := h.Parser.Parse(text)
-	if err != nil {
-		log.Error(err.Error())
-		return !h.AffectsParsing
-	} else if h.Callback != nil {
-		h.Callback(value)
-		return true
-	} else if h.Ptr != nil {
-		*h.Ptr = value
-		return true
-	} else {
-		log.Error("missing consumer")
-		return !h.AffectsParsing
-	}
-}
-
-func (h *StringHandler) Complete(text string, observer CompletionObserver) {
-	h.Parser.Complete(text, observer)
-}
-
-func (h *StringHandler) TypeName() string {
-	return h.Parser.TypeName()
-}
+var String StringHandlerFactory = NewValue[string](&SimpleStringParser{})
 
 type Enum struct {
 	Possible []string
@@ -86,7 +38,12 @@ func (p *Enum) Parse(text string) (string, error) {
 			return text, nil
 		}
 	}
-	return "", &parseError{message: fmt.Sprintf("%#v is not in %s", text, p.TypeName())}
+	message := fmt.Sprintf("%#v is not in %s", text, p.TypeName())
+	suggestion := suggest(text, p.Possible)
+	if suggestion != "" {
+		message += fmt.Sprintf(", did you mean %#v?", suggestion)
+	}
+	return "", &parseError{message: message}
 }
 
 func (p *Enum) Complete(text string, observer CompletionObserver) {
@@ -101,12 +58,20 @@ func (p *Enum) TypeName() string {
 	return fmt.Sprintf("{%s}", strings.Join(p.Possible, ","))
 }
 
+func (p *Enum) Choices() []string {
+	return p.Possible
+}
+
 func (p *Enum) Set(ptr *string) ValueHandler {
-	return &StringHandler{Parser: p, Ptr: ptr}
+	return NewValue[string](p).Set(ptr)
 }
 
 func (p *Enum) Call(callback func(value string)) ValueHandler {
-	return &StringHandler{Parser: p, Callback: callback}
+	return NewValue[string](p).Call(callback)
+}
+
+func (p *Enum) Append(ptr *[]string) ValueHandler {
+	return NewValue[string](p).Append(ptr)
 }
 
 type FilePath struct {
@@ -177,9 +142,13 @@ func (p *FilePath) TypeName() string {
 }
 
 func (p *FilePath) Set(ptr *string) ValueHandler {
-	return &StringHandler{Parser: p, Ptr: ptr}
+	return NewValue[string](p).Set(ptr)
 }
 
 func (p *FilePath) Call(callback func(value string)) ValueHandler {
-	return &StringHandler{Parser: p, Callback: callback}
+	return NewValue[string](p).Call(callback)
+}
+
+func (p *FilePath) Append(ptr *[]string) ValueHandler {
+	return NewValue[string](p).Append(ptr)
 }
@@ -0,0 +1,145 @@
+package cmdline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var durationUnits = []string{"ms", "s", "m", "h"}
+
+// numericPrefix returns the length of text up to and including its last
+// digit, or 0 if it contains no digits.
+func numericPrefix(text string) int {
+	return strings.LastIndexAny(text, "0123456789") + 1
+}
+
+type DurationParser struct {
+}
+
+func (p *DurationParser) Parse(text string) (time.Duration, error) {
+	value, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, &parseError{message: fmt.Sprintf("%#v cannot be converted into a duration", text)}
+	}
+	return value, nil
+}
+
+// Complete suggests a unit once a number has been typed.
+func (p *DurationParser) Complete(text string, observer CompletionObserver) {
+	split := numericPrefix(text)
+	if split <= 0 {
+		return
+	}
+	number, unit := text[:split], text[split:]
+	for _, possible := range durationUnits {
+		if strings.HasPrefix(possible, unit) {
+			observer.FinalCompletion(number + possible)
+		}
+	}
+}
+
+func (p *DurationParser) TypeName() string {
+	return "duration"
+}
+
+var Duration HandlerFactory[time.Duration] = NewValue[time.Duration](&DurationParser{})
+
+// TimeParser parses timestamps written in any of Layouts, see time.Parse.  If
+// Relative is set, a signed duration such as "-2h" is also accepted and
+// interpreted relative to the current time.
+type TimeParser struct {
+	Layouts  []string
+	Relative bool
+	// Now returns the current time, it defaults to time.Now.
+	Now func() time.Time
+}
+
+func (p *TimeParser) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func isRelativeTime(text string) bool {
+	return strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+")
+}
+
+func (p *TimeParser) Parse(text string) (time.Time, error) {
+	if p.Relative && isRelativeTime(text) {
+		offset, err := time.ParseDuration(text)
+		if err == nil {
+			return p.now().Add(offset), nil
+		}
+	}
+	for _, layout := range p.Layouts {
+		value, err := time.Parse(layout, text)
+		if err == nil {
+			return value, nil
+		}
+	}
+	return time.Time{}, &parseError{message: fmt.Sprintf("%#v cannot be converted into a %s", text, p.TypeName())}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// matchesShape checks that text could be the start of example, treating all
+// digits as interchangeable.  Text ending part way through a number does not
+// match, filling out the rest of it could produce nonsense like month 13.
+func matchesShape(text string, example string) bool {
+	if len(text) >= len(example) {
+		return false
+	}
+	if len(text) > 0 && isDigit(example[len(text)-1]) && isDigit(example[len(text)]) {
+		return false
+	}
+	for i := 0; i < len(text); i++ {
+		t, e := text[i], example[i]
+		if isDigit(e) {
+			if !isDigit(t) {
+				return false
+			}
+		} else if t != e {
+			return false
+		}
+	}
+	return true
+}
+
+// Complete fills out the rest of a timestamp from the current time, for each
+// layout the typed text is consistent with.
+func (p *TimeParser) Complete(text string, observer CompletionObserver) {
+	if p.Relative && isRelativeTime(text) {
+		(&DurationParser{}).Complete(text, observer)
+		return
+	}
+	now := p.now()
+	seen := map[string]bool{}
+	for _, layout := range p.Layouts {
+		example := now.Format(layout)
+		if !matchesShape(text, example) {
+			continue
+		}
+		completion := text + example[len(text):]
+		if !seen[completion] {
+			seen[completion] = true
+			observer.FinalCompletion(completion)
+		}
+	}
+}
+
+func (p *TimeParser) TypeName() string {
+	formats := append([]string{}, p.Layouts...)
+	if p.Relative {
+		formats = append(formats, "±duration")
+	}
+	return "time (" + strings.Join(formats, " or ") + ")"
+}
+
+var Time HandlerFactory[time.Time] = NewValue[time.Time](&TimeParser{
+	Layouts:  []string{time.RFC3339, "2006-01-02"},
+	Relative: true,
+})
@@ -0,0 +1,69 @@
+package cmdline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	p := &DurationParser{}
+	value, err := p.Parse("1m30s")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, value)
+	_, err = p.Parse("30")
+	assert.EqualError(t, err, `"30" cannot be converted into a duration`)
+}
+
+func TestCompleteDuration(t *testing.T) {
+	p := &DurationParser{}
+	c := &parser{}
+	p.Complete("30", c)
+	assert.Equal(t, []string{"30ms", "30s", "30m", "30h"}, c.completions)
+	c = &parser{}
+	p.Complete("1h30m", c)
+	assert.Equal(t, []string{"1h30ms", "1h30m"}, c.completions)
+	c = &parser{}
+	p.Complete("", c)
+	assert.Equal(t, []string(nil), c.completions)
+}
+
+func makeTimeParser() *TimeParser {
+	now := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	return &TimeParser{
+		Layouts:  []string{time.RFC3339, "2006-01-02"},
+		Relative: true,
+		Now:      func() time.Time { return now },
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	p := makeTimeParser()
+	value, err := p.Parse("2024-01-01T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), value)
+	value, err = p.Parse("2024-01-02")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), value)
+	value, err = p.Parse("-2h")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), value)
+	_, err = p.Parse("yesterday")
+	assert.EqualError(t, err, `"yesterday" cannot be converted into a time (2006-01-02T15:04:05Z07:00 or 2006-01-02 or ±duration)`)
+}
+
+func TestCompleteTime(t *testing.T) {
+	p := makeTimeParser()
+	c := &parser{}
+	p.Complete("2023-12", c)
+	assert.Equal(t, []string{"2023-12-15T12:30:00Z", "2023-12-15"}, c.completions)
+	c = &parser{}
+	p.Complete("2023-1", c)
+	assert.Equal(t, []string(nil), c.completions)
+	c = &parser{}
+	p.Complete("2023-01-01T", c)
+	assert.Equal(t, []string{"2023-01-01T12:30:00Z"}, c.completions)
+	c = &parser{}
+	p.Complete("-2", c)
+	assert.Equal(t, []string{"-2ms", "-2s", "-2m", "-2h"}, c.completions)
+}